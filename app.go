@@ -2,7 +2,7 @@ package main
 
 import (
 	"fmt"
-	"os"
+	"slices"
 	"sort"
 	"strings"
 
@@ -13,41 +13,53 @@ import (
  * Memos *
  *********/
 
-func AddMemo(ui *Ui, config *Config) {
-	content := ""
-	title := ""
+func AddMemo(ui *Ui, config *Config, args *Args) {
+	title := args.Arg(0)
+	content := args.Arg(1)
 	tags := []string{}
-	for i := 2; i < len(os.Args); i++ {
-		arg := strings.TrimSpace(os.Args[i])
-		if arg == "-t" || arg == "--tags" {
-			if i+1 == len(os.Args) {
-				cliError("No tags specified")
-			}
-			i++
-			tags = strings.Split(strings.TrimSpace(os.Args[i]), ",")
-		} else if title == "" {
-			title = arg
-		} else {
-			content = arg
-		}
+	if args.Has("tags") {
+		tags = strings.Split(strings.TrimSpace(args.Value("tags")), ",")
 	}
+	no_run := args.Has("no-run")
+	memo_type := ""
+	if args.Has("type") {
+		memo_type = ParseType(args.Value("type"))
+	}
+	suggestions := ParseVars(args.All("suggest"))
 
 	if title == "" {
 		cliError("No memo title given")
 	}
 
+	from_stdin := content == STDIN_ARG || (content == "" && StdinIsPiped())
+	if from_stdin {
+		content = ReadStdin()
+		if content == "" {
+			dataError("No content given on stdin")
+		}
+	}
+
 	// title := strings.TrimSpace(os.Args[2])
 
-	memos := LoadMemos(config.SavesDir)
+	// Titles only need to be unique within a store
+	saves_dir := NewMemoDir(config, args)
+	memos := LoadMemos(saves_dir)
 	for _, memo := range memos {
 		if memo.Title == title {
+			if from_stdin {
+				dataError(fmt.Sprintf("Memo '%s' already exists. Use `%s %s '%s' -` to replace its content.", title, APP_NAME, CMD_EDIT, title))
+			}
+			ui.RequirePrompt(
+				fmt.Sprintf("Memo '%s' already exists. Edit?", title),
+				fmt.Sprintf("Use `%s %s '%s'` to change it.", APP_NAME, CMD_EDIT, title),
+			)
 			response := ui.GetResponse(
 				fmt.Sprintf("Memo '%s' already exists.\nEdit? (y/n) ", title),
 				"Invalid response. Try again: ",
 				[]string{"y", "n"},
 			)
 			if response == "y" {
-				EditMemo(ui, config) // inefficient but simple
+				EditMemo(ui, config, args) // inefficient but simple
 			}
 
 			return
@@ -57,124 +69,235 @@ func AddMemo(ui *Ui, config *Config) {
 	// var content string
 	// if len(os.Args) < 4 {
 	if content == "" {
-		content = ui.EditContent("")
+		edited, err := ui.EditContent(config, "", TypeExtension(memo_type))
+		if err != nil {
+			dataError(fmt.Sprintf("Memo not added: %v", err), EditExitStatus(err))
+		}
+		content = edited
 	}
 	memo := CreateMemo(title, content)
 	for _, tag := range tags {
 		memo.Tags = append(memo.Tags, tag)
 	}
-	hash := memo.Save(config.SavesDir)
+	memo.NoRun = no_run
+	memo.Type = memo_type
+	memo.Dir = saves_dir
+	if memo.Type == "" {
+		memo.Type = DetectType(content)
+	}
+	if len(suggestions) > 0 {
+		memo.Suggestions = suggestions
+	}
+	hash := memo.SaveOrExit()
 	fmt.Println(hash[0:8])
 }
 
-func EditMemo(ui *Ui, config *Config) {
-	identifier := ""
-	new_content := ""
-	auto_accept := false
-	for i := 2; i < len(os.Args); i++ {
-		arg := strings.TrimSpace(os.Args[i])
-		if arg == "-a" || arg == "--accept" {
-			auto_accept = true
-		} else if identifier == "" {
-			identifier = arg
-		} else {
-			new_content = arg
-		}
+func EditMemo(ui *Ui, config *Config, args *Args) {
+	identifier := args.Arg(0)
+	new_content := args.Arg(1)
+	auto_accept := args.Has("accept")
+	var no_run *bool = nil
+	if args.Has("no-run") && args.Has("runnable") {
+		cliError("Only one of (--no-run) and (--runnable) can be given")
+	} else if args.Has("no-run") || args.Has("runnable") {
+		value := args.Has("no-run")
+		no_run = &value
 	}
+	memo_type := ""
+	if args.Has("type") {
+		memo_type = ParseType(args.Value("type"))
+	}
+	suggestions := ParseVars(args.All("suggest"))
 
 	if identifier == "" {
 		cliError("No memo hash/title given")
 	}
 
-	memos := LoadMemos(config.SavesDir)
-	var memo_to_edit *Memo = nil
-	for hash, memo := range memos {
-		if hash[0:8] == identifier || memo.Title == identifier {
-			memo_to_edit = memo
-			break
+	memos := LoadAllMemos(config)
+	_, memo_to_edit := ui.ResolveMemo(memos, identifier)
+
+	has_settings := no_run != nil || memo_type != "" || len(suggestions) > 0
+	if new_content == STDIN_ARG || (new_content == "" && !has_settings && StdinIsPiped()) {
+		new_content = ReadStdin()
+		if new_content == "" {
+			dataError("No content given on stdin")
 		}
+		// stdin is used up, so ask on the terminal
+		ui = ui.OnTty()
 	}
 
-	if memo_to_edit == nil {
-		dataError(fmt.Sprintf("Unknown memo identifier '%s'\n", identifier))
+	original := FormatFrontMatter(memo_to_edit)
+
+	// Settings given without new content are saved without editing
+	if has_settings {
+		if no_run != nil {
+			memo_to_edit.NoRun = *no_run
+		}
+		if memo_type != "" {
+			memo_to_edit.Type = memo_type
+		}
+		for name, command := range suggestions {
+			if command == "" {
+				delete(memo_to_edit.Suggestions, name)
+			} else {
+				if memo_to_edit.Suggestions == nil {
+					memo_to_edit.Suggestions = make(map[string]string)
+				}
+				memo_to_edit.Suggestions[name] = command
+			}
+		}
+		if new_content == "" {
+			memo_to_edit.SaveOrExit()
+			return
+		}
 	}
 
-	if new_content == "" {
-		new_content = ui.EditContent(memo_to_edit.Content)
+	var updated *Memo
+	if new_content != "" {
+		edited := *memo_to_edit
+		edited.Content = new_content
+		updated = &edited
+	} else {
+		// The title, tags and other fields are edited along with the
+		// content, reopening the editor until they are valid
+		document := original
+		for updated == nil {
+			edited, err := ui.EditContent(config, document, TypeExtension(memo_to_edit.Type))
+			if err != nil {
+				dataError(fmt.Sprintf("Changes scrapped: %v", err), EditExitStatus(err))
+			}
+			updated, err = ParseFrontMatter(edited, memo_to_edit, memos)
+			if err != nil {
+				fmt.Fprintf(ui.Out, "Invalid memo: %v\n", err)
+				document = AddFrontMatterError(edited, err)
+			}
+		}
+	}
+	if updated.Type == "" {
+		updated.Type = DetectType(updated.Content)
 	}
 
 	if !auto_accept {
 		// Unmaintained package
 		diff := difflib.UnifiedDiff{
-			A:        difflib.SplitLines(memo_to_edit.Content),
-			B:        difflib.SplitLines(new_content),
+			A:        difflib.SplitLines(original),
+			B:        difflib.SplitLines(FormatFrontMatter(updated)),
 			FromFile: "Original",
 			ToFile:   "New",
 			Context:  3,
 		}
 		text, _ := difflib.GetUnifiedDiffString(diff)
-		fmt.Println(text)
-		fmt.Println("Changes:")
+		if text == "" {
+			infof("No changes")
+			return
+		}
+		ui.RequirePrompt("Accept changes?", "Use (-a/--accept) to accept them.")
+		fmt.Fprintln(ui.Out, text)
+		fmt.Fprintln(ui.Out, "Changes:")
 		response := ui.GetResponse(
 			"Accept changes? (y/n) ",
 			"Try again: ",
 			[]string{"y", "n"},
 		)
 		if response == "n" {
-			fmt.Println("Changes scrapped")
-			return
+			dataError("Changes scrapped", EXIT_ABORTED)
 		}
 	}
 
-	memo_to_edit.Content = new_content
-	memo_to_edit.Save(config.SavesDir)
+	if updated.Title == memo_to_edit.Title {
+		updated.SaveOrExit()
+		return
+	}
+	// Memos are stored by title, so the old file goes
+	batch := CreateBatch()
+	batch.Save(updated)
+	batch.Delete(memo_to_edit)
+	if err := batch.Commit(); err != nil {
+		dataError(fmt.Sprintf("Not renamed: %v", err), EXIT_STORAGE)
+	}
+	infof("Renamed to '%s' (%s)", updated.Title, updated.Hash()[0:8])
 }
 
-func RemoveMemo(ui *Ui, config *Config) {
-	if len(os.Args) < 3 {
-		cliError("No memo identifier given")
+func RemoveMemo(ui *Ui, config *Config, args *Args) {
+	memos := LoadAllMemos(config)
+	hashes, ui := ui.TargetMemos(memos, args.Arg(0), args)
+	if !ui.ConfirmChange(memos, hashes, "Remove", args) {
+		return
 	}
-	identifier := strings.TrimSpace(os.Args[2])
 
-	if identifier == "" {
-		cliError("No memo hash/title given")
+	batch := CreateBatch()
+	for _, hash := range hashes {
+		batch.Delete(memos[hash])
+	}
+	if err := batch.Commit(); err != nil {
+		dataError(fmt.Sprintf("Nothing removed: %v", err), EXIT_STORAGE)
 	}
+}
 
-	memos := LoadMemos(config.SavesDir)
-	var memo_to_remove *Memo = nil
-	for hash, memo := range memos {
-		if hash[0:8] == identifier || memo.Title == identifier {
-			memo_to_remove = memo
-			break
+// Moves memos to the notebook (--to), from whichever store they're in
+func MoveMemo(ui *Ui, config *Config, args *Args) {
+	to := strings.TrimSpace(args.Value("to"))
+	if to == "" {
+		cliError("(--to NOTEBOOK) is required")
+	}
+	dir, found := NotebookDir(config, to)
+	if !found {
+		unknownNotebook(config, to)
+	}
+	memos := LoadAllMemos(config)
+	hashes, ui := ui.TargetMemos(memos, args.Arg(0), args)
+	if len(hashes) == 1 && memos[hashes[0]].Dir == dir {
+		infof("Memo '%s' is already in notebook '%s'", memos[hashes[0]].Title, to)
+		return
+	}
+	// Only those elsewhere move
+	hashes = slices.DeleteFunc(hashes, func(hash HASH) bool { return memos[hash].Dir == dir })
+
+	// Titles only need to be unique within a store. A local memo gets a new
+	// hash in the notebook, so the clashes are found by the hashes they'd have.
+	existing := LoadMemos(dir)
+	moving := make(map[HASH]bool)
+	clashes := []string{}
+	for _, hash := range hashes {
+		moved_hash := MemoHash(dir, ToFilename(memos[hash].Title, ""))
+		if _, found := existing[moved_hash]; found {
+			clashes = append(clashes, fmt.Sprintf("'%s'", memos[hash].Title))
+		} else if moving[moved_hash] {
+			dataError(fmt.Sprintf("More than one memo titled '%s' would move to notebook '%s'", memos[hash].Title, to))
 		}
+		moving[moved_hash] = true
 	}
-
-	if memo_to_remove == nil {
-		dataError(fmt.Sprintf("Unknown memo identifier '%s'\n", identifier))
+	if len(clashes) > 0 {
+		dataError(fmt.Sprintf("Notebook '%s' already has memos titled %s", to, strings.Join(clashes, ", ")))
+	}
+	if !ui.ConfirmChange(memos, hashes, "Move", args) {
+		return
 	}
 
-	memo_to_remove.Delete(config.SavesDir)
+	batch := CreateBatch()
+	for _, hash := range hashes {
+		moved := *memos[hash]
+		moved.Dir = dir
+		batch.Save(&moved)
+		batch.Delete(memos[hash])
+	}
+	if err := batch.Commit(); err != nil {
+		dataError(fmt.Sprintf("Nothing moved: %v", err), EXIT_STORAGE)
+	}
+	infof("Moved to notebook '%s'", to)
 }
 
-func SearchMemos(ui *Ui, config *Config) {
-	skip_formatting := false
-	search_term := ""
-	title_only := false
-	content_only := false
-	for i := 2; i < len(os.Args); i++ {
-		arg := strings.TrimSpace(os.Args[i])
-		if arg == "-n" || arg == "--no-format" {
-			skip_formatting = true
-		} else if arg == "-t" || arg == "--title" {
-			title_only = true
-		} else if arg == "-c" || arg == "--content" {
-			content_only = true
-		} else {
-			search_term = arg
-		}
+func SearchMemos(ui *Ui, config *Config, args *Args) {
+	print_options := CreatePrintOptions(config)
+	print_options.ApplyArgs(args)
+	search_term := strings.Join(args.Positional, " ")
+	title_only := args.Has("title")
+	content_only := args.Has("content")
+	if title_only && content_only {
+		cliError("You can only limit the search with one of (-t/--title) and (-c/--content)")
 	}
 
-	memos := LoadMemos(config.SavesDir)
+	memos := LoadStores(config, args)
 	memos_to_print := make(map[string]*Memo)
 	for hash, memo := range memos {
 		if MemoMatchesSearch(search_term, memo, title_only, content_only) {
@@ -182,7 +305,7 @@ func SearchMemos(ui *Ui, config *Config) {
 		}
 	}
 
-	ui.PrintMemos(memos_to_print, skip_formatting)
+	ui.PrintMemos(memos_to_print, print_options)
 }
 
 func MemoMatchesSearch(search_term string, memo *Memo, title_only bool, content_only bool) bool {
@@ -196,127 +319,116 @@ func MemoMatchesSearch(search_term string, memo *Memo, title_only bool, content_
 		strings.Contains(strings.ToLower(memo.Content), strings.ToLower(search_term))
 }
 
-func ShowMemo(ui *Ui, config *Config) {
-	skip_formatting := false
-	identifier := ""
-	for i := 2; i < len(os.Args); i++ {
-		arg := strings.TrimSpace(os.Args[i])
-		if arg == "-n" || arg == "--no-format" {
-			skip_formatting = true
-		} else {
-			identifier = arg
-		}
-	}
+func ShowMemo(ui *Ui, config *Config, args *Args) {
+	print_options := CreatePrintOptions(config)
+	print_options.ApplyArgs(args)
+	identifier := args.Arg(0)
+	fill := args.Has("fill")
+	copy_content := args.Has("copy")
+	vars := ParseVars(args.All("var"))
 
 	if identifier == "" {
 		cliError("No memo hash/title given")
 	}
 
-	memos := LoadMemos(config.SavesDir)
-	var memo_to_print *Memo = nil
-	var hash_to_print HASH = ""
-	for hash, memo := range memos {
-		if hash[0:8] == identifier || memo.Title == identifier {
-			hash_to_print = hash
-			memo_to_print = memo
-			break
-		}
+	memos := LoadAllMemos(config)
+	hash_to_print, memo_to_print := ui.ResolveMemo(memos, identifier)
+	if fill {
+		filled := *memo_to_print
+		filled.Content = ui.OnTty().FillMemo(memo_to_print, vars, config)
+		memo_to_print = &filled
 	}
-
-	if memo_to_print == nil {
-		dataError(fmt.Sprintf("Unknown memo identifier '%s'\n", identifier))
+	print_options.Highlight = UseColor()
+	if copy_content {
+		if err := CopyToClipboard(config, memo_to_print.Content); err != nil {
+			dataError(fmt.Sprintf("Could not copy to the clipboard: %v", err))
+		}
 	}
 	memos_to_print := make(map[string]*Memo)
 	memos_to_print[hash_to_print] = memo_to_print
 
-	ui.PrintMemos(memos_to_print, skip_formatting)
+	ui.PrintMemos(memos_to_print, print_options)
 }
 
-func ShowMemos(ui *Ui, config *Config) {
-	skip_formatting := false
-	search_tags_map := make(map[string]bool)
-	for i := 2; i < len(os.Args); i++ {
-		arg := strings.TrimSpace(os.Args[i])
-		var tag string
-		if arg == "-n" || arg == "--no-format" {
-			skip_formatting = true
-		} else if arg == "-t" || arg == "--tag" {
-			if len(os.Args) < i+1 {
-				cliError("Invalid tag search a")
-			}
-			tag = strings.TrimSpace(os.Args[i+1])
-			if tag != "-t" && tag != "--tag" {
-				search_tags_map[tag] = true
-				i++
-			} else {
-				cliError("Invalid tag search")
-			}
+func ShowMemos(ui *Ui, config *Config, args *Args) {
+	print_options := CreatePrintOptions(config)
+	print_options.ApplyArgs(args)
+	grouped := args.Has("grouped")
+	if args.Has("ids") {
+		if grouped {
+			cliError("Only one of (-g/--grouped) and (--ids) can be given")
 		}
+		print_options.SkipFormatting = true
+		print_options.Columns = []string{COLUMN_HASH}
 	}
-	search_tags := []string{}
-	for s := range search_tags_map {
-		search_tags = append(search_tags, s)
-	}
-	memos := LoadMemos(config.SavesDir)
+	memos := LoadStores(config, args)
 	memos_to_print := make(map[string]*Memo)
-	for hash, memo := range memos {
-		if len(search_tags) == 0 || AnyIntersection(search_tags, memo.Tags) {
-			memos_to_print[hash] = memo
-		}
+	for _, hash := range SelectMemos(memos, args) {
+		memos_to_print[hash] = memos[hash]
 	}
 
-	ui.PrintMemos(memos_to_print, skip_formatting)
+	if grouped {
+		ui.PrintMemosGrouped(memos_to_print, print_options.SkipFormatting)
+	} else {
+		ui.PrintMemos(memos_to_print, print_options)
+	}
 }
 
 /********
  * Tags *
  ********/
 
-func AddTag(config *Config) {
-	if len(os.Args) < 4 {
-		cliError("No memo hash")
+func AddTag(ui *Ui, config *Config, args *Args) {
+	tag := strings.TrimSpace(args.Arg(1))
+	memos := LoadAllMemos(config)
+	hashes, ui := ui.TargetMemos(memos, args.Arg(0), args)
+	if len(hashes) == 1 && slices.Contains(memos[hashes[0]].Tags, tag) {
+		infof("Memo '%s' already has tag '%s'", memos[hashes[0]].Title, tag)
+		return
 	}
-	memo_hash := strings.TrimSpace(os.Args[3])
-	memo := LoadMemoByHash(config.SavesDir, memo_hash)
-	if memo == nil {
-		dataError(fmt.Sprintf("No memo identifier '%s'", memo_hash))
+	// Only those without the tag change
+	hashes = slices.DeleteFunc(hashes, func(hash HASH) bool { return slices.Contains(memos[hash].Tags, tag) })
+	if !ui.ConfirmChange(memos, hashes, fmt.Sprintf("Add tag '%s' to", tag), args) {
+		return
+	}
+
+	batch := CreateBatch()
+	for _, hash := range hashes {
+		memos[hash].Tags = append(memos[hash].Tags, tag)
+		batch.Save(memos[hash])
 	}
-	if len(os.Args) < 5 {
-		cliError("No tag")
+	if err := batch.Commit(); err != nil {
+		dataError(fmt.Sprintf("No tags added: %v", err), EXIT_STORAGE)
 	}
-	tag := strings.TrimSpace(os.Args[4])
-	memo.Tags = append(memo.Tags, tag)
-	memo.Save(config.SavesDir)
 }
 
-func RemoveTag(config *Config) {
-	if len(os.Args) < 4 {
-		cliError("No memo hash")
+func RemoveTag(ui *Ui, config *Config, args *Args) {
+	tag := strings.TrimSpace(args.Arg(1))
+	memos := LoadAllMemos(config)
+	hashes, ui := ui.TargetMemos(memos, args.Arg(0), args)
+	if len(hashes) == 1 && !slices.Contains(memos[hashes[0]].Tags, tag) {
+		dataError(fmt.Sprintf("Memo '%s' has no tag '%s'", memos[hashes[0]].Title, tag))
 	}
-	memo_hash := strings.TrimSpace(os.Args[3])
-	memo := LoadMemoByHash(config.SavesDir, memo_hash)
-	if memo == nil {
-		dataError(fmt.Sprintf("No memo identifier '%s'", memo_hash))
+	// Only those with the tag change
+	hashes = slices.DeleteFunc(hashes, func(hash HASH) bool { return !slices.Contains(memos[hash].Tags, tag) })
+	if !ui.ConfirmChange(memos, hashes, fmt.Sprintf("Remove tag '%s' from", tag), args) {
+		return
 	}
-	if len(os.Args) < 5 {
-		cliError("No tag")
+
+	batch := CreateBatch()
+	for _, hash := range hashes {
+		memo := memos[hash]
+		memo.Tags = slices.DeleteFunc(memo.Tags, func(other string) bool { return other == tag })
+		batch.Save(memo)
 	}
-	tag := strings.TrimSpace(os.Args[4])
-	var i int
-	var found_tag string
-	for i, found_tag = range memo.Tags {
-		if found_tag == tag {
-			break
-		}
+	if err := batch.Commit(); err != nil {
+		dataError(fmt.Sprintf("No tags removed: %v", err), EXIT_STORAGE)
 	}
-
-	memo.Tags = append(memo.Tags[:i], memo.Tags[i+1:]...)
-	memo.Save(config.SavesDir)
 }
 
 func ShowTags(config *Config) {
 	tags := make(map[string]bool)
-	memos := LoadMemos(config.SavesDir)
+	memos := LoadAllMemos(config)
 	for _, memo := range memos {
 		for _, tag := range memo.Tags {
 			tags[tag] = true
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// A set of memos to save and delete together, in any of the stores. Commit
+// writes the new files to a staging directory in each memo's saves directory
+// and moves the old ones aside before putting anything in place, so a
+// failure part way leaves the saves as they were.
+type Batch struct {
+	saves   []*Memo
+	deletes []*Memo
+}
+
+func CreateBatch() *Batch {
+	return &Batch{}
+}
+
+func (batch *Batch) Save(memo *Memo) {
+	batch.saves = append(batch.saves, memo)
+}
+
+func (batch *Batch) Delete(memo *Memo) {
+	batch.deletes = append(batch.deletes, memo)
+}
+
+func (batch *Batch) Commit() (err error) {
+	// Renames only work within a filesystem, so each saves directory gets
+	// its own. A directory, so LoadMemos skips it if it is ever left behind.
+	stagings := make(map[string]string)
+	defer func() {
+		for _, staging := range stagings {
+			os.RemoveAll(staging)
+		}
+	}()
+	for _, memo := range append(append([]*Memo{}, batch.saves...), batch.deletes...) {
+		if _, found := stagings[memo.Dir]; !found {
+			staging, err := os.MkdirTemp(memo.Dir, ".batch-")
+			if err != nil {
+				return err
+			}
+			stagings[memo.Dir] = staging
+		}
+	}
+	for _, memo := range batch.saves {
+		memo.Touch()
+		data, err := json.MarshalIndent(memo, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(stagings[memo.Dir], ToFilename(memo.Title, "")+".new"), data, 0644); err != nil {
+			return err
+		}
+	}
+
+	// Undone in reverse if anything fails from here on
+	undo := []func(){}
+	defer func() {
+		if err != nil {
+			for i := len(undo) - 1; i >= 0; i-- {
+				undo[i]()
+			}
+		}
+	}()
+	move := func(from string, to string) error {
+		if err := os.Rename(from, to); err != nil {
+			return err
+		}
+		undo = append(undo, func() { os.Rename(to, from) })
+		return nil
+	}
+
+	// Everything replaced or deleted is moved aside first
+	for _, memo := range append(append([]*Memo{}, batch.saves...), batch.deletes...) {
+		filename := ToFilename(memo.Title, "")
+		err = move(filepath.Join(memo.Dir, filename), filepath.Join(stagings[memo.Dir], filename+".old"))
+		if errors.Is(err, os.ErrNotExist) {
+			err = nil
+		} else if err != nil {
+			return fmt.Errorf("could not move '%s' aside: %v", filename, err)
+		}
+	}
+	for _, memo := range batch.saves {
+		filename := ToFilename(memo.Title, "")
+		if err = move(filepath.Join(stagings[memo.Dir], filename+".new"), filepath.Join(memo.Dir, filename)); err != nil {
+			return fmt.Errorf("could not write '%s': %v", filename, err)
+		}
+		debugf("Wrote '%s'", filepath.Join(memo.Dir, filename))
+	}
+	for _, memo := range batch.deletes {
+		debugf("Deleted '%s'", filepath.Join(memo.Dir, ToFilename(memo.Title, "")))
+	}
+	return nil
+}
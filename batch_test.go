@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// The files in dir and their contents
+func readSaves(t *testing.T, dir string) map[string]string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[entry.Name()] = string(data)
+	}
+	return files
+}
+
+// A memo in the saves directory dir
+func createTestMemo(dir string, title string, content string) *Memo {
+	memo := CreateMemo(title, content)
+	memo.Dir = dir
+	return memo
+}
+
+func TestBatchCommit(t *testing.T) {
+	dir := t.TempDir()
+	old := createTestMemo(dir, "Old", "old")
+	old.Save()
+	kept := createTestMemo(dir, "Kept", "kept")
+	kept.Save()
+
+	batch := CreateBatch()
+	kept.Content = "changed"
+	batch.Save(kept)
+	batch.Save(createTestMemo(dir, "New", "new"))
+	batch.Delete(old)
+	if err := batch.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	memos := LoadMemos(dir)
+	titles := []string{}
+	for _, memo := range memos {
+		titles = append(titles, memo.Title+"="+memo.Content)
+	}
+	slices.Sort(titles)
+	if want := []string{"Kept=changed", "New=new"}; !slices.Equal(titles, want) {
+		t.Errorf("after Commit() the memos are %q, want %q", titles, want)
+	}
+	if files := readSaves(t, dir); len(files) != 2 {
+		t.Errorf("Commit() left files behind: %v", files)
+	}
+}
+
+func TestBatchCommitUndo(t *testing.T) {
+	dir, other_dir := t.TempDir(), t.TempDir()
+	createTestMemo(dir, "Deploy", "original").Save()
+	createTestMemo(other_dir, "Old", "old").Save()
+	before := []map[string]string{readSaves(t, dir), readSaves(t, other_dir)}
+
+	// Both are saved in the same file, so the second can't be put in place
+	// once the first is, and everything done so far is undone, in either store
+	batch := CreateBatch()
+	batch.Delete(createTestMemo(other_dir, "Old", ""))
+	batch.Save(createTestMemo(dir, "Deploy", "first"))
+	batch.Save(createTestMemo(dir, "deploy", "second"))
+	if err := batch.Commit(); err == nil {
+		t.Fatal("Commit() should fail")
+	}
+
+	after := []map[string]string{readSaves(t, dir), readSaves(t, other_dir)}
+	for i := range before {
+		if len(after[i]) != len(before[i]) {
+			t.Errorf("after a failed Commit() the files are %q, want %q", after[i], before[i])
+		}
+		for name, content := range before[i] {
+			if after[i][name] != content {
+				t.Errorf("after a failed Commit() '%s' is %q, want %q", name, after[i][name], content)
+			}
+		}
+	}
+}
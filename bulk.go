@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Commands changing memos take either an IDENTIFIER, `-` to read
+// identifiers from stdin, one per line, or a selection by (-t/--tag) and
+// (-q/--query). More than one memo is listed and confirmed before changing.
+
+var SELECT_FLAGS = []Flag{
+	{Long: "tag", Short: "t", Value: "TAG", Repeat: true, Help: "Selects the memos with ANY of the given tags."},
+	{Long: "query", Short: "q", Value: "QUERY", Help: "Selects the memos matching QUERY. " + QUERY_HELP},
+}
+
+var BULK_FLAGS = []Flag{
+	{Long: "yes", Short: "y", Help: "Changes more than one memo without asking to confirm."},
+	{Long: "dry-run", Help: "Prints the memos that would change without changing them."},
+}
+
+const BULK_HELP = "An IDENTIFIER of `-` reads identifiers from stdin, one per line, and without an IDENTIFIER (-t/--tag) and (-q/--query) select the memos. More than one memo is listed and confirmed before it changes."
+
+const QUERY_HELP = "Every word must match, ignoring case: a plain word the title or content, and title:WORD, content:WORD, tag:TAG or type:TYPE only that field. Words can be quoted to include spaces."
+
+const (
+	QUERY_TITLE   = "title"
+	QUERY_CONTENT = "content"
+	QUERY_TAG     = "tag"
+	QUERY_TYPE    = "type"
+)
+
+type QueryTerm struct {
+	Field string // empty for the title or content
+	Value string
+}
+
+func ParseQuery(query string) ([]QueryTerm, error) {
+	terms := []QueryTerm{}
+	if strings.TrimSpace(query) == "" {
+		return terms, nil
+	}
+	words, err := ShellWords(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query '%s': %v", query, err)
+	}
+	for _, word := range words {
+		field, value, found := strings.Cut(word, ":")
+		switch strings.ToLower(field) {
+		case QUERY_TITLE, QUERY_CONTENT, QUERY_TAG, QUERY_TYPE:
+			if found {
+				terms = append(terms, QueryTerm{Field: strings.ToLower(field), Value: strings.ToLower(value)})
+				continue
+			}
+		}
+		terms = append(terms, QueryTerm{Value: strings.ToLower(word)})
+	}
+	return terms, nil
+}
+
+func MemoMatchesQuery(terms []QueryTerm, memo *Memo) bool {
+	for _, term := range terms {
+		title := strings.Contains(strings.ToLower(memo.Title), term.Value)
+		content := strings.Contains(strings.ToLower(memo.Content), term.Value)
+		matches := false
+		switch term.Field {
+		case QUERY_TITLE:
+			matches = title
+		case QUERY_CONTENT:
+			matches = content
+		case QUERY_TAG:
+			for _, tag := range memo.Tags {
+				matches = matches || strings.ToLower(tag) == term.Value
+			}
+		case QUERY_TYPE:
+			matches = strings.ToLower(memo.Type) == term.Value
+		default:
+			matches = title || content
+		}
+		if !matches {
+			return false
+		}
+	}
+	return true
+}
+
+// Whether args select memos with (-t/--tag) or (-q/--query)
+func HasSelection(args *Args) bool {
+	return args.Has("tag") || args.Has("query")
+}
+
+// The memos matching (-t/--tag) and (-q/--query), sorted by title
+func SelectMemos(memos map[HASH]*Memo, args *Args) []HASH {
+	tags := []string{}
+	for _, tag := range args.All("tag") {
+		tags = append(tags, strings.TrimSpace(tag))
+	}
+	terms, err := ParseQuery(args.Value("query"))
+	if err != nil {
+		cliError(err.Error())
+	}
+
+	selected := []HASH{}
+	for hash, memo := range memos {
+		if (len(tags) == 0 || AnyIntersection(tags, memo.Tags)) && MemoMatchesQuery(terms, memo) {
+			selected = append(selected, hash)
+		}
+	}
+	SortByTitle(memos, selected)
+	return selected
+}
+
+func SortByTitle(memos map[HASH]*Memo, hashes []HASH) {
+	sort.Slice(hashes, func(i, j int) bool {
+		return strings.ToLower(memos[hashes[i]].Title) < strings.ToLower(memos[hashes[j]].Title)
+	})
+}
+
+// The memos a changing command applies to, from its IDENTIFIER, `-` or a
+// selection. Returns the Ui to prompt on, as stdin may be used up.
+func (ui *Ui) TargetMemos(memos map[HASH]*Memo, identifier string, args *Args) ([]HASH, *Ui) {
+	if identifier != "" && HasSelection(args) {
+		cliError("Give either IDENTIFIER or a selection with (-t/--tag) and (-q/--query), not both")
+	}
+
+	switch {
+	case identifier == STDIN_ARG:
+		hashes := []HASH{}
+		unknown := []string{}
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			// `memo ls` output may follow the hash with other columns
+			line, _, _ = strings.Cut(line, "\t")
+			hash, err := FindMemo(memos, line)
+			if err != nil {
+				unknown = append(unknown, fmt.Sprintf("'%s' (%v)", line, err))
+			} else if !slices.Contains(hashes, hash) {
+				hashes = append(hashes, hash)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			dataError(fmt.Sprintf("Could not read stdin: %v", err))
+		}
+		if len(unknown) > 0 {
+			dataError("Unknown memo identifiers: "+strings.Join(unknown, ", "), EXIT_NOT_FOUND)
+		}
+		return hashes, ui.OnTty()
+	case identifier != "":
+		hash, _ := ui.ResolveMemo(memos, identifier)
+		return []HASH{hash}, ui
+	case HasSelection(args):
+		return SelectMemos(memos, args), ui
+	default:
+		cliError("No memo identifier given. Use IDENTIFIER, `-` to read them from stdin, or (-t/--tag) and (-q/--query)")
+	}
+	return nil, ui
+}
+
+// Lists the memos about to change and asks to go ahead, unless there is
+// only one named by its identifier or (-y/--yes) is given. With (--dry-run)
+// the list goes to stdout and nothing is changed. Returns whether to apply
+// the change, described by action, e.g. "Remove".
+func (ui *Ui) ConfirmChange(memos map[HASH]*Memo, hashes []HASH, action string, args *Args) bool {
+	if len(hashes) == 0 {
+		infof("No memos to change")
+		return false
+	}
+
+	dry_run := args.Has("dry-run")
+	single := len(hashes) == 1 && args.Arg(0) != "" && args.Arg(0) != STDIN_ARG
+	if single && !dry_run {
+		return true
+	}
+
+	out := ui.Out
+	if dry_run {
+		out = os.Stdout
+	}
+	noun := "memos"
+	if len(hashes) == 1 {
+		noun = "memo"
+	}
+	fmt.Fprintf(out, "%s %d %s:\n", action, len(hashes), noun)
+	for _, hash := range hashes {
+		fmt.Fprintf(out, "    %s  %s\n", hash[0:8], memos[hash].Title)
+	}
+	if dry_run {
+		return false
+	}
+	if args.Has("yes") {
+		return true
+	}
+	ui.RequirePrompt("Go ahead?", "Use (-y/--yes) to go ahead without asking.")
+	response := ui.GetResponse("Go ahead? (y/n) ", "Try again: ", []string{"y", "n"})
+	if response == "n" {
+		dataError("Nothing changed", EXIT_ABORTED)
+	}
+	return true
+}
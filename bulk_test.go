@@ -0,0 +1,66 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		terms []QueryTerm // nil for an error
+	}{
+		{"", []QueryTerm{}},
+		{"  ", []QueryTerm{}},
+		{"Docker", []QueryTerm{{"", "docker"}}},
+		{"title:Deploy tag:Web TYPE:sh content:ssh", []QueryTerm{{QUERY_TITLE, "deploy"}, {QUERY_TAG, "web"}, {QUERY_TYPE, "sh"}, {QUERY_CONTENT, "ssh"}}},
+		{`"docker compose" title:"deploy steps"`, []QueryTerm{{"", "docker compose"}, {QUERY_TITLE, "deploy steps"}}},
+		// Only known fields, and only with a colon
+		{"http://host tag", []QueryTerm{{"", "http://host"}, {"", "tag"}}},
+		{"tag:", []QueryTerm{{QUERY_TAG, ""}}},
+		{`"unterminated`, nil},
+	}
+	for _, test := range tests {
+		terms, err := ParseQuery(test.query)
+		if test.terms == nil {
+			if err == nil {
+				t.Errorf("ParseQuery(%q) = %v, expected an error", test.query, terms)
+			}
+		} else if err != nil || !slices.Equal(terms, test.terms) {
+			t.Errorf("ParseQuery(%q) = %v, %v, want %v", test.query, terms, err, test.terms)
+		}
+	}
+}
+
+func TestMemoMatchesQuery(t *testing.T) {
+	memo := &Memo{Title: "Deploy steps", Content: "ssh prod\nmake deploy", Tags: []string{"Work", "ops"}, Type: "sh"}
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"", true},
+		{"deploy", true},
+		{"SSH", true},
+		{"deploy ssh", true},
+		{"deploy docker", false},
+		{"title:steps", true},
+		{"title:ssh", false},
+		{"content:ssh", true},
+		{"content:steps", false},
+		{"tag:work", true},
+		{"tag:wor", false},
+		{"type:SH", true},
+		{"type:bash", false},
+		{`"prod make"`, false},
+		{`"ssh prod"`, true},
+	}
+	for _, test := range tests {
+		terms, err := ParseQuery(test.query)
+		if err != nil {
+			t.Fatalf("ParseQuery(%q): %v", test.query, err)
+		}
+		if got := MemoMatchesQuery(terms, memo); got != test.want {
+			t.Errorf("MemoMatchesQuery(%q) = %v, want %v", test.query, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	SHELL_BASH = "bash"
+	SHELL_ZSH  = "zsh"
+	SHELL_FISH = "fish"
+)
+
+func CaptureMemo(ui *Ui, config *Config, args *Args) {
+	title := strings.TrimSpace(args.Value("title"))
+	tags := []string{}
+	for _, tag := range args.All("tag") {
+		tags = append(tags, strings.TrimSpace(tag))
+	}
+
+	var content string
+	if StdinIsPiped() {
+		content = strings.TrimSpace(ReadStdin())
+		// stdin is used up, so ask on the terminal
+		ui = ui.OnTty()
+	} else {
+		path := HistoryFile()
+		commands, err := ReadHistory(path)
+		if err != nil {
+			dataError(fmt.Sprintf("Could not read history from '%s': %v", path, err))
+		}
+		content = LastCommand(commands)
+	}
+	if content == "" {
+		dataError("No command to capture")
+	}
+
+	// Titles only need to be unique within a store
+	saves_dir := NewMemoDir(config, args)
+	memos := LoadMemos(saves_dir)
+	titles := make(map[string]bool)
+	for _, memo := range memos {
+		titles[memo.Title] = true
+	}
+	if titles[title] {
+		dataError(fmt.Sprintf("Memo '%s' already exists", title))
+	}
+
+	fmt.Fprintln(ui.Out, content)
+	if title == "" {
+		ui.RequirePrompt("Title:", "Use (--title) to give it.")
+	}
+	for title == "" {
+		title = ui.GetText("Title: ", "A title is required: ")
+		if titles[title] {
+			fmt.Fprintf(ui.Out, "Memo '%s' already exists. ", title)
+			title = ""
+		}
+	}
+
+	memo := CreateMemo(title, content)
+	memo.Tags = append(memo.Tags, tags...)
+	memo.Type = DetectType(content)
+	memo.Dir = saves_dir
+	hash := memo.SaveOrExit()
+	fmt.Println(hash[0:8])
+}
+
+// $HISTFILE if the shell exports it, otherwise the default for $SHELL
+func HistoryFile() string {
+	if path := os.Getenv("HISTFILE"); path != "" {
+		return path
+	}
+	home, _ := os.UserHomeDir()
+	if filepath.Base(os.Getenv("SHELL")) == SHELL_ZSH {
+		return filepath.Join(home, ".zsh_history")
+	}
+	return filepath.Join(home, ".bash_history")
+}
+
+// bash writes `#<timestamp>` before each command when HISTTIMEFORMAT is set
+var bashTimestampPattern = regexp.MustCompile(`^#[0-9]+$`)
+
+// zsh's EXTENDED_HISTORY writes `: <timestamp>:<duration>;<command>`
+var zshExtendedPattern = regexp.MustCompile(`^: [0-9]+:[0-9]+;`)
+
+// Reads the commands from a bash or zsh history file, oldest first. zsh
+// continues multi-line commands with a trailing backslash.
+func ReadHistory(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	commands := []string{}
+	continuing := false
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if continuing {
+			commands[len(commands)-1] += "\n" + strings.TrimSuffix(line, "\\")
+			continuing = strings.HasSuffix(line, "\\")
+			continue
+		}
+		if bashTimestampPattern.MatchString(line) {
+			continue
+		}
+		line = zshExtendedPattern.ReplaceAllString(line, "")
+		continuing = strings.HasSuffix(line, "\\")
+		commands = append(commands, strings.TrimSuffix(line, "\\"))
+	}
+	return commands, scanner.Err()
+}
+
+// The most recent command that isn't blank or capturing itself
+func LastCommand(commands []string) string {
+	for i := len(commands) - 1; i >= 0; i-- {
+		command := strings.TrimSpace(commands[i])
+		if command == "" ||
+			strings.HasPrefix(command, APP_NAME+" "+CMD_CAPTURE) ||
+			strings.HasPrefix(command, APP_NAME+"-"+CMD_CAPTURE) {
+			continue
+		}
+		return command
+	}
+	return ""
+}
+
+func PrintShellInit(args *Args) {
+	script := ""
+	switch args.Arg(0) {
+	case SHELL_BASH:
+		script = SHELL_INIT_BASH
+	case SHELL_ZSH:
+		script = SHELL_INIT_ZSH
+	case SHELL_FISH:
+		script = SHELL_INIT_FISH
+	default:
+		cliError(fmt.Sprintf("Unknown shell '%s', expected one of %s, %s or %s", args.Arg(0), SHELL_BASH, SHELL_ZSH, SHELL_FISH))
+	}
+	os.Stdout.WriteString(script)
+}
+
+// Each defines a `memo-capture` function, which saves the command before it,
+// and binds Ctrl-X m to save the last command without typing anything. The
+// command is piped in because shells only write their history file on exit.
+const SHELL_INIT_BASH = `# memo shell integration. Add to ~/.bashrc:
+#     eval "$(memo shell-init bash)"
+memo-capture() {
+    # The newest history entry is this call, so take the one before
+    fc -ln -2 -2 | memo capture "$@"
+}
+__memo_capture_last() {
+    fc -ln -1 -1 | memo capture
+}
+bind -x '"\C-xm": __memo_capture_last'
+`
+
+const SHELL_INIT_ZSH = `# memo shell integration. Add to ~/.zshrc:
+#     eval "$(memo shell-init zsh)"
+memo-capture() {
+    # The newest history entry is this call, so take the one before
+    fc -ln -2 -2 | memo capture "$@"
+}
+__memo_capture_last() {
+    zle -I
+    fc -ln -1 -1 | memo capture
+}
+zle -N __memo_capture_last
+bindkey '^Xm' __memo_capture_last
+`
+
+const SHELL_INIT_FISH = `# memo shell integration. Add to ~/.config/fish/config.fish:
+#     memo shell-init fish | source
+function memo-capture
+    # The newest history entry is this call, so take the one before
+    set -l commands (history --null --max 2 | string split0)
+    printf '%s\n' $commands[2] | memo capture $argv
+end
+function __memo_capture_last
+    set -l commands (history --null --max 1 | string split0)
+    printf '%s\n' $commands[1] | memo capture
+    commandline -f repaint
+end
+bind \cxm __memo_capture_last
+`
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestReadHistory(t *testing.T) {
+	tests := []struct {
+		history string
+		want    []string
+	}{
+		{"", []string{}},
+		{"ls\ncd /tmp\n", []string{"ls", "cd /tmp"}},
+		// bash with HISTTIMEFORMAT set
+		{"#1700000000\nls\n#1700000005\ngit status\n", []string{"ls", "git status"}},
+		// zsh with EXTENDED_HISTORY
+		{": 1700000000:0;ls\n: 1700000005:12;make test\n", []string{"ls", "make test"}},
+		// zsh multi-line commands, with and without EXTENDED_HISTORY
+		{": 1700000000:0;for f in *; do\\\n  echo $f\\\ndone\nls\n", []string{"for f in *; do\n  echo $f\ndone", "ls"}},
+		{"echo a \\\n  b\n", []string{"echo a \n  b"}},
+		// Only whole lines of digits are timestamps
+		{"#1700000000\n# comment\n#12ab\n", []string{"# comment", "#12ab"}},
+	}
+	for _, test := range tests {
+		path := filepath.Join(t.TempDir(), "history")
+		if err := os.WriteFile(path, []byte(test.history), 0600); err != nil {
+			t.Fatal(err)
+		}
+		got, err := ReadHistory(path)
+		if err != nil || !slices.Equal(got, test.want) {
+			t.Errorf("ReadHistory(%q) = %q, %v, want %q", test.history, got, err, test.want)
+		}
+	}
+
+	if _, err := ReadHistory(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Errorf("ReadHistory() of a missing file should fail")
+	}
+}
+
+func TestLastCommand(t *testing.T) {
+	tests := []struct {
+		commands []string
+		want     string
+	}{
+		{[]string{}, ""},
+		{[]string{"ls", "  git status  "}, "git status"},
+		// Blank lines and capturing itself are skipped
+		{[]string{"make test", "", "memo capture Tests", "memo-capture"}, "make test"},
+		{[]string{"memo capture"}, ""},
+		{[]string{"ls", "memo ls"}, "memo ls"},
+	}
+	for _, test := range tests {
+		if got := LastCommand(test.commands); got != test.want {
+			t.Errorf("LastCommand(%q) = %q, want %q", test.commands, got, test.want)
+		}
+	}
+}
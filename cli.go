@@ -0,0 +1,369 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Commands, their flags and arguments are declared in COMMANDS, which both
+// parses the command line and generates the help.
+
+type Flag struct {
+	Long   string // without the leading --
+	Short  string // a single letter, without the leading -
+	Value  string // name of the flag's value, empty for switches
+	Repeat bool   // may be given more than once
+	Help   string
+}
+
+type Arg struct {
+	Name     string
+	Optional bool
+	Variadic bool // takes the rest of the arguments
+}
+
+type Command struct {
+	Name        string
+	Aliases     []string
+	Args        []Arg
+	Flags       []Flag
+	Extra       string // name for arguments after --, which are refused if empty
+	Help        string
+	Examples    []Example
+	Exits       []Exit // DEFAULT_EXITS if empty
+	Hidden      bool   // left out of the help and generated docs
+	Unparsed    bool   // gets the arguments as they are, flags included
+	Subcommands []*Command
+	Run         func(args *Args)
+}
+
+// A parsed command line
+type Args struct {
+	Command    *Command
+	Positional []string
+	Values     map[string][]string // by the flag's long name, "" for switches
+	Extra      []string            // after --
+}
+
+func (command *Command) Matches(name string) bool {
+	if command.Name == name {
+		return true
+	}
+	for _, alias := range command.Aliases {
+		if alias == name {
+			return true
+		}
+	}
+	return false
+}
+
+func FindCommand(commands []*Command, name string) *Command {
+	for _, command := range commands {
+		if command.Matches(name) {
+			return command
+		}
+	}
+	return nil
+}
+
+// The command's flag, or one of GLOBAL_FLAGS
+func (command *Command) LongFlag(name string) *Flag {
+	for i, flag := range command.Flags {
+		if flag.Long == name {
+			return &command.Flags[i]
+		}
+	}
+	for i, flag := range GLOBAL_FLAGS {
+		if flag.Long == name {
+			return &GLOBAL_FLAGS[i]
+		}
+	}
+	return nil
+}
+
+func (command *Command) ShortFlag(name string) *Flag {
+	for i, flag := range command.Flags {
+		if flag.Short != "" && flag.Short == name {
+			return &command.Flags[i]
+		}
+	}
+	return nil
+}
+
+// Finds the command named by argv, following subcommands, and runs it.
+// prefix is the command line so far, for errors.
+func Dispatch(commands []*Command, prefix string, argv []string) {
+	// Global flags can also come before the command
+	global := &Args{Values: make(map[string][]string)}
+	for len(argv) > 0 && strings.HasPrefix(argv[0], "--") {
+		name, value, has_value := strings.Cut(argv[0][2:], "=")
+		flag := (&Command{}).LongFlag(name)
+		if flag == nil {
+			break
+		}
+		argv = argv[1:]
+		if flag.Value == "" && has_value {
+			cliError(fmt.Sprintf("%s doesn't take a value", FlagName(flag)))
+		} else if flag.Value != "" && !has_value {
+			if len(argv) == 0 {
+				cliError(fmt.Sprintf("No %s given for %s", flag.Value, FlagName(flag)))
+			}
+			value, argv = argv[0], argv[1:]
+		}
+		global.Values[flag.Long] = append(global.Values[flag.Long], value)
+	}
+	ApplyGlobalFlags(global)
+
+	if len(argv) == 0 && prefix == APP_NAME {
+		cliError("No arguments given")
+	} else if len(argv) == 0 {
+		cliError(fmt.Sprintf("No command given after '%s'", prefix))
+	}
+	name := strings.TrimSpace(argv[0])
+	command := FindCommand(commands, name)
+	if command == nil {
+		cliError(fmt.Sprintf("Unknown command '%s'", strings.TrimSpace(prefix+" "+name)))
+	}
+	current_command, current_prefix = command, prefix
+	if command.Unparsed {
+		command.Run(&Args{Command: command, Positional: argv[1:], Values: make(map[string][]string), Extra: []string{}})
+		return
+	}
+	if len(command.Subcommands) > 0 {
+		if len(argv) > 1 && IsHelpArg(argv[1]) {
+			PrintCommandHelp(CommandPath{Prefix: prefix, Command: command})
+			return
+		}
+		Dispatch(command.Subcommands, prefix+" "+command.Name, argv[1:])
+		return
+	}
+	for _, arg := range argv[1:] {
+		if arg == "--" {
+			break
+		}
+		if IsHelpArg(arg) {
+			PrintCommandHelp(CommandPath{Prefix: prefix, Command: command})
+			return
+		}
+	}
+
+	args, err := ParseArgs(command, argv[1:])
+	if err != nil {
+		cliError(fmt.Sprintf("%s %s: %v", prefix, command.Name, err))
+	}
+	ApplyGlobalFlags(args)
+	command.Run(args)
+}
+
+func IsHelpArg(arg string) bool {
+	return arg == HELP || arg == HELP_SHORT
+}
+
+// Parses argv against the command's declarations. Flags and arguments can
+// come in any order; flags take values as `--flag value`, `--flag=value`,
+// `-f value` or `-fvalue`, switches can be combined as `-abc` and everything
+// after `--` is an argument.
+func ParseArgs(command *Command, argv []string) (*Args, error) {
+	args := &Args{
+		Command:    command,
+		Positional: []string{},
+		Values:     make(map[string][]string),
+		Extra:      []string{},
+	}
+	set := func(flag *Flag, value string) error {
+		if _, ok := args.Values[flag.Long]; ok && !flag.Repeat {
+			return fmt.Errorf("%s given more than once", FlagName(flag))
+		}
+		args.Values[flag.Long] = append(args.Values[flag.Long], value)
+		return nil
+	}
+
+	for i := 0; i < len(argv); i++ {
+		arg := argv[i]
+		next_value := func(flag *Flag) (string, error) {
+			if i+1 == len(argv) {
+				return "", fmt.Errorf("no %s given for %s", flag.Value, FlagName(flag))
+			}
+			i++
+			return argv[i], nil
+		}
+
+		switch {
+		case arg == "--":
+			if command.Extra != "" {
+				args.Extra = append(args.Extra, argv[i+1:]...)
+			} else {
+				for _, positional := range argv[i+1:] {
+					args.Positional = append(args.Positional, strings.TrimSpace(positional))
+				}
+			}
+			i = len(argv)
+		case strings.HasPrefix(arg, "--"):
+			name, value, has_value := strings.Cut(arg[2:], "=")
+			flag := command.LongFlag(name)
+			if flag == nil {
+				return nil, fmt.Errorf("unknown flag '--%s'", name)
+			}
+			if flag.Value == "" && has_value {
+				return nil, fmt.Errorf("%s doesn't take a value", FlagName(flag))
+			}
+			if flag.Value != "" && !has_value {
+				var err error
+				if value, err = next_value(flag); err != nil {
+					return nil, err
+				}
+			}
+			if err := set(flag, value); err != nil {
+				return nil, err
+			}
+		case strings.HasPrefix(arg, "-") && arg != STDIN_ARG:
+			shorts := []rune(arg[1:])
+			for j, short := range shorts {
+				flag := command.ShortFlag(string(short))
+				if flag == nil {
+					return nil, fmt.Errorf("unknown flag '-%c'", short)
+				}
+				value := ""
+				if flag.Value != "" {
+					if j+1 < len(shorts) {
+						value = string(shorts[j+1:])
+					} else {
+						var err error
+						if value, err = next_value(flag); err != nil {
+							return nil, err
+						}
+					}
+				}
+				if err := set(flag, value); err != nil {
+					return nil, err
+				}
+				if flag.Value != "" {
+					break
+				}
+			}
+		default:
+			args.Positional = append(args.Positional, strings.TrimSpace(arg))
+		}
+	}
+
+	variadic := len(command.Args) > 0 && command.Args[len(command.Args)-1].Variadic
+	if len(args.Positional) > len(command.Args) && !variadic {
+		unexpected := args.Positional[len(command.Args)]
+		if command.Extra != "" {
+			return nil, fmt.Errorf("unexpected argument '%s'. %s go after '--'", unexpected, command.Extra)
+		}
+		return nil, fmt.Errorf("unexpected argument '%s'", unexpected)
+	}
+
+	// Optional arguments are only filled once the required ones are, so
+	// `(<A>) <B>` takes a single argument as B. Those skipped are left
+	// empty to keep the rest at their declared index.
+	required := 0
+	for _, declared := range command.Args {
+		if !declared.Optional {
+			required++
+		}
+	}
+	spare := len(args.Positional) - required
+	given := args.Positional
+	args.Positional = []string{}
+	for _, declared := range command.Args {
+		if len(given) == 0 {
+			if !declared.Optional {
+				return nil, fmt.Errorf("no %s given", declared.Name)
+			}
+			continue
+		}
+		if declared.Variadic {
+			args.Positional = append(args.Positional, given...)
+			given = nil
+			continue
+		}
+		if declared.Optional && spare <= 0 {
+			args.Positional = append(args.Positional, "")
+			continue
+		}
+		if declared.Optional {
+			spare--
+		}
+		args.Positional = append(args.Positional, given[0])
+		given = given[1:]
+	}
+	return args, nil
+}
+
+// Whether the flag was given
+func (args *Args) Has(name string) bool {
+	_, ok := args.Values[name]
+	return ok
+}
+
+// The flag's value, the last if given more than once
+func (args *Args) Value(name string) string {
+	values := args.Values[name]
+	if len(values) == 0 {
+		return ""
+	}
+	return values[len(values)-1]
+}
+
+func (args *Args) All(name string) []string {
+	return args.Values[name]
+}
+
+// The positional argument at i, or "" if not given
+func (args *Args) Arg(i int) string {
+	if i < len(args.Positional) {
+		return args.Positional[i]
+	}
+	return ""
+}
+
+// `-t/--tag` style name of a flag
+func FlagName(flag *Flag) string {
+	if flag.Short != "" {
+		return fmt.Sprintf("-%s/--%s", flag.Short, flag.Long)
+	}
+	return "--" + flag.Long
+}
+
+// e.g. `(...-t/--tag <TAG>)`
+func FlagUsage(flag *Flag) string {
+	usage := FlagName(flag)
+	if flag.Value != "" {
+		value := strings.ReplaceAll("<"+flag.Value+">", "=", ">=<")
+		usage += " " + value
+	}
+	if flag.Repeat {
+		usage = "..." + usage
+	}
+	return "(" + usage + ")"
+}
+
+// The command's synopsis, e.g. `memo add (-t/--tags <TAGS>) <TITLE> (<CONTENTS>)`
+func (command *Command) Usage(prefix string) string {
+	names := append([]string{command.Name}, command.Aliases...)
+	parts := []string{prefix}
+	if len(names) > 1 {
+		parts = append(parts, "("+strings.Join(names, "/")+")")
+	} else {
+		parts = append(parts, command.Name)
+	}
+	for i := range command.Flags {
+		parts = append(parts, FlagUsage(&command.Flags[i]))
+	}
+	for _, arg := range command.Args {
+		usage := "<" + arg.Name + ">"
+		if arg.Variadic {
+			usage += "..."
+		}
+		if arg.Optional {
+			usage = "(" + usage + ")"
+		}
+		parts = append(parts, usage)
+	}
+	if command.Extra != "" {
+		parts = append(parts, "(-- <"+command.Extra+">...)")
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestParseArgs(t *testing.T) {
+	add := &Command{
+		Name: "add",
+		Args: []Arg{{Name: "TITLE"}, {Name: "CONTENTS", Optional: true}},
+		Flags: []Flag{
+			{Long: "tag", Short: "t", Value: "TAG", Repeat: true},
+			{Long: "yes", Short: "y"},
+			{Long: "all", Short: "a"},
+			{Long: "type", Value: "TYPE"},
+		},
+	}
+	run := &Command{Name: "run", Args: []Arg{{Name: "IDENTIFIER"}}, Extra: "ARGS"}
+	tag := &Command{Name: "tag", Args: []Arg{{Name: "IDENTIFIER", Optional: true}, {Name: "TAG"}}}
+	search := &Command{Name: "search", Args: []Arg{{Name: "TERM", Optional: true, Variadic: true}}}
+
+	tests := []struct {
+		command    *Command
+		argv       []string
+		ok         bool
+		positional []string
+		values     map[string][]string
+		extra      []string
+	}{
+		{add, []string{"title"}, true, []string{"title"}, map[string][]string{}, []string{}},
+		{add, []string{" title ", "body", "-y"}, true, []string{"title", "body"}, map[string][]string{"yes": {""}}, []string{}},
+		{add, []string{"-ya", "title"}, true, []string{"title"}, map[string][]string{"yes": {""}, "all": {""}}, []string{}},
+		{add, []string{"-tnet", "-t", "unix", "--tag=web", "--tag", "db", "x"}, true, []string{"x"}, map[string][]string{"tag": {"net", "unix", "web", "db"}}, []string{}},
+		{add, []string{"-yt", "net", "x"}, true, []string{"x"}, map[string][]string{"yes": {""}, "tag": {"net"}}, []string{}},
+		{add, []string{"--type=sh", "x"}, true, []string{"x"}, map[string][]string{"type": {"sh"}}, []string{}},
+		{add, []string{"--type", "--yes", "x"}, true, []string{"x"}, map[string][]string{"type": {"--yes"}}, []string{}},
+		// Everything after -- is an argument, and - is stdin
+		{add, []string{"--", "-x", "--y"}, true, []string{"-x", "--y"}, map[string][]string{}, []string{}},
+		{add, []string{"-", "x"}, true, []string{"-", "x"}, map[string][]string{}, []string{}},
+		// Any command takes the global flags
+		{add, []string{"x", "--quiet"}, true, []string{"x"}, map[string][]string{"quiet": {""}}, []string{}},
+		{add, []string{}, false, nil, nil, nil},
+		{add, []string{"a", "b", "c"}, false, nil, nil, nil},
+		{add, []string{"--nope", "x"}, false, nil, nil, nil},
+		{add, []string{"-z", "x"}, false, nil, nil, nil},
+		{add, []string{"--yes=1", "x"}, false, nil, nil, nil},
+		{add, []string{"x", "--type"}, false, nil, nil, nil},
+		{add, []string{"x", "-t"}, false, nil, nil, nil},
+		{add, []string{"x", "--type", "a", "--type", "b"}, false, nil, nil, nil},
+		{add, []string{"x", "-y", "--yes"}, false, nil, nil, nil},
+		{run, []string{"id", "--", "-la", "/tmp"}, true, []string{"id"}, map[string][]string{}, []string{"-la", "/tmp"}},
+		{run, []string{"id", "more"}, false, nil, nil, nil},
+		// An optional argument before a required one is only filled when
+		// there are enough arguments, and is left empty otherwise
+		{tag, []string{"web"}, true, []string{"", "web"}, map[string][]string{}, []string{}},
+		{tag, []string{"id", "web"}, true, []string{"id", "web"}, map[string][]string{}, []string{}},
+		{tag, []string{}, false, nil, nil, nil},
+		{tag, []string{"a", "b", "c"}, false, nil, nil, nil},
+		{search, []string{"a", "b", "c"}, true, []string{"a", "b", "c"}, map[string][]string{}, []string{}},
+		{search, []string{}, true, []string{}, map[string][]string{}, []string{}},
+	}
+	for _, test := range tests {
+		args, err := ParseArgs(test.command, test.argv)
+		if !test.ok {
+			if err == nil {
+				t.Errorf("%s %q: expected an error, got %+v", test.command.Name, test.argv, args)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %q: unexpected error %v", test.command.Name, test.argv, err)
+			continue
+		}
+		if !slices.Equal(args.Positional, test.positional) || !maps.EqualFunc(args.Values, test.values, slices.Equal) || !slices.Equal(args.Extra, test.extra) {
+			t.Errorf("%s %q = %q %q %q, want %q %q %q", test.command.Name, test.argv, args.Positional, args.Values, args.Extra, test.positional, test.values, test.extra)
+		}
+	}
+}
+
+func TestArgsAccessors(t *testing.T) {
+	args := &Args{Positional: []string{"a"}, Values: map[string][]string{"tag": {"x", "y"}, "yes": {""}}}
+	if !args.Has("yes") || args.Has("all") {
+		t.Errorf("Has() got the switches wrong")
+	}
+	if args.Value("tag") != "y" || args.Value("all") != "" {
+		t.Errorf("Value() should be the last value given, or empty")
+	}
+	if args.Arg(0) != "a" || args.Arg(1) != "" {
+		t.Errorf("Arg() should be the positional argument, or empty")
+	}
+}
+
+func TestUsage(t *testing.T) {
+	command := &Command{
+		Name:    "ls",
+		Aliases: []string{"list"},
+		Args:    []Arg{{Name: "TERM", Optional: true, Variadic: true}},
+		Flags:   []Flag{{Long: "tag", Short: "t", Value: "TAG", Repeat: true}, {Long: "var", Value: "NAME=VALUE"}},
+		Extra:   "ARGS",
+	}
+	want := "memo (ls/list) (...-t/--tag <TAG>) (--var <NAME>=<VALUE>) (<TERM>...) (-- <ARGS>...)"
+	if got := command.Usage("memo"); got != want {
+		t.Errorf("Usage() = %q, want %q", got, want)
+	}
+}
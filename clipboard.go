@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const (
+	CLIPBOARD_AUTO  = "auto"
+	CLIPBOARD_OSC52 = "osc52"
+)
+
+// Clipboard commands tried in order, when the environment suits them
+var clipboardTools = []struct {
+	Command []string
+	Env     string // only tried when this is set
+}{
+	{Command: []string{"wl-copy"}, Env: "WAYLAND_DISPLAY"},
+	{Command: []string{"xclip", "-selection", "clipboard"}, Env: "DISPLAY"},
+	{Command: []string{"xsel", "--clipboard", "--input"}, Env: "DISPLAY"},
+	{Command: []string{"pbcopy"}},
+	{Command: []string{"clip.exe"}},
+}
+
+// Puts content on the clipboard. Config.Clipboard is either "auto" (the
+// default), "osc52" or a command that reads the content from stdin.
+//
+// In auto mode the OSC 52 escape sequence is sent to the terminal, which also
+// works over ssh, and any local clipboard tool is used too since not every
+// terminal supports OSC 52.
+func CopyToClipboard(config *Config, content string) error {
+	setting := strings.TrimSpace(config.Clipboard)
+	switch setting {
+	case "", CLIPBOARD_AUTO:
+		osc52_err := CopyWithOsc52(content)
+		tool_err := errors.New("no clipboard tool found")
+		for _, tool := range clipboardTools {
+			if tool.Env != "" && os.Getenv(tool.Env) == "" {
+				continue
+			}
+			if _, err := exec.LookPath(tool.Command[0]); err == nil {
+				tool_err = CopyWithCommand(tool.Command, content)
+				break
+			}
+		}
+		if osc52_err != nil && tool_err != nil {
+			return fmt.Errorf("%v and %v", osc52_err, tool_err)
+		}
+		return nil
+	case CLIPBOARD_OSC52:
+		return CopyWithOsc52(content)
+	}
+	command, err := ShellWords(setting)
+	if err != nil {
+		return fmt.Errorf("could not read clipboard command '%s': %v", setting, err)
+	}
+	return CopyWithCommand(command, content)
+}
+
+func CopyWithOsc52(content string) error {
+	if os.Getenv("TERM") == "dumb" {
+		return errors.New("terminal does not support OSC 52")
+	}
+	in, tty, err := OpenTty()
+	if err != nil {
+		return fmt.Errorf("no terminal for OSC 52: %v", err)
+	}
+	defer in.Close()
+	if tty != in {
+		defer tty.Close()
+	}
+
+	sequence := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(content)) + "\a"
+	if os.Getenv("TMUX") != "" {
+		// tmux only passes escape sequences through to the outer terminal
+		// when wrapped, with inner escapes doubled
+		sequence = "\x1bPtmux;" + strings.ReplaceAll(sequence, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+	_, err = tty.WriteString(sequence)
+	return err
+}
+
+func CopyWithCommand(command []string, content string) error {
+	if len(command) == 0 {
+		return errors.New("no clipboard command")
+	}
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin = strings.NewReader(content)
+	// xclip and wl-copy leave a child behind holding the selection, which
+	// keeps stderr open, so it isn't waited on once the tool itself exits
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = 100 * time.Millisecond
+	if err := cmd.Run(); err != nil && !errors.Is(err, exec.ErrWaitDelay) {
+		return fmt.Errorf("'%s' failed: %v %s", strings.Join(command, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+func CopyMemo(ui *Ui, config *Config, args *Args) {
+	identifier := args.Arg(0)
+	fill := args.Has("fill")
+	vars := ParseVars(args.All("var"))
+
+	if identifier == "" {
+		cliError("No memo hash/title given")
+	}
+
+	memos := LoadAllMemos(config)
+	_, memo_to_copy := ui.ResolveMemo(memos, identifier)
+
+	content := memo_to_copy.Content
+	if fill {
+		content = ui.FillMemo(memo_to_copy, vars, config)
+	}
+	if err := CopyToClipboard(config, content); err != nil {
+		dataError(fmt.Sprintf("Could not copy to the clipboard: %v", err))
+	}
+	infof("Copied '%s' to the clipboard", memo_to_copy.Title)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCopyWithCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the commands need sh")
+	}
+	copied := filepath.Join(t.TempDir(), "copied")
+	tests := []struct {
+		command []string
+		err     string // in the error, empty for none
+	}{
+		{[]string{"sh", "-c", "cat > " + copied}, ""},
+		// Like xclip, leaves a child holding the selection
+		{[]string{"sh", "-c", "cat > " + copied + "; sleep 5 &"}, ""},
+		{[]string{"sh", "-c", "echo no display >&2; exit 1"}, "no display"},
+		{[]string{}, "no clipboard command"},
+	}
+	for _, test := range tests {
+		start := time.Now()
+		err := CopyWithCommand(test.command, "content")
+		if time.Since(start) > 2*time.Second {
+			t.Errorf("CopyWithCommand(%q) waited on the tool's child", test.command)
+		}
+		if test.err == "" {
+			if err != nil {
+				t.Errorf("CopyWithCommand(%q) = %v", test.command, err)
+			} else if data, _ := os.ReadFile(copied); string(data) != "content" {
+				t.Errorf("CopyWithCommand(%q) copied %q", test.command, data)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("CopyWithCommand(%q) = %v, want an error with %q", test.command, err, test.err)
+		}
+	}
+}
+
+func TestCopyToClipboardCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the command needs sh")
+	}
+	copied := filepath.Join(t.TempDir(), "copied file")
+	// Quoted words, as with the Editor setting
+	config := &Config{Clipboard: `sh -c 'cat > "$0"' '` + copied + `'`}
+	if err := CopyToClipboard(config, "content"); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(copied); string(data) != "content" {
+		t.Errorf("CopyToClipboard() copied %q", data)
+	}
+	if err := CopyToClipboard(&Config{Clipboard: `sh -c 'unterminated`}, "content"); err == nil {
+		t.Errorf("CopyToClipboard() should fail with an unterminated quote")
+	}
+}
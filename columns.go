@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	COLUMN_HASH    = "hash"
+	COLUMN_TITLE   = "title"
+	COLUMN_CONTENT = "content"
+	COLUMN_TAGS    = "tags"
+	COLUMN_TYPE    = "type"
+	COLUMN_CREATED = "created"
+	COLUMN_UPDATED = "updated"
+	COLUMN_SOURCE  = "source"
+
+	SORT_HASH    = "hash"
+	SORT_TITLE   = "title"
+	SORT_CREATED = "created"
+	SORT_UPDATED = "updated"
+	SORT_TAG     = "tag"
+
+	COLUMN_GAP       = 4
+	COLUMN_MIN_WIDTH = 4
+	DATE_FORMAT      = "2006-01-02 15:04"
+	ELLIPSIS         = "…"
+)
+
+var ALL_COLUMNS = []string{COLUMN_HASH, COLUMN_TITLE, COLUMN_CONTENT, COLUMN_TAGS, COLUMN_TYPE, COLUMN_CREATED, COLUMN_UPDATED, COLUMN_SOURCE}
+var DEFAULT_COLUMNS = []string{COLUMN_HASH, COLUMN_TITLE, COLUMN_CONTENT, COLUMN_TAGS}
+var ALL_SORTS = []string{SORT_TITLE, SORT_CREATED, SORT_UPDATED, SORT_TAG, SORT_HASH}
+
+// Columns whose values wrap and can be narrowed to fit the terminal
+var COLUMN_FLEXIBLE = map[string]bool{
+	COLUMN_TITLE:   true,
+	COLUMN_CONTENT: true,
+	COLUMN_TAGS:    true,
+}
+
+type PrintOptions struct {
+	SkipFormatting bool
+	Columns        []string
+	Sort           string
+	Reverse        bool
+	ContentLines   int            // 0 for no limit
+	ColumnWidths   map[string]int // maximum width per column
+	Highlight      bool           // syntax highlight content by memo type
+}
+
+func CreatePrintOptions(config *Config) *PrintOptions {
+	columns := config.Columns
+	// Inside a project, memos come from two stores
+	if local_dir != "" && slices.Equal(columns, DEFAULT_COLUMNS) {
+		columns = append(slices.Clone(columns), COLUMN_SOURCE)
+	}
+	return &PrintOptions{
+		SkipFormatting: config.Format == FORMAT_PLAIN,
+		Columns:        columns,
+		Sort:           config.Sort,
+		Reverse:        false,
+		ContentLines:   0,
+		ColumnWidths:   config.ColumnWidths,
+		Highlight:      false,
+	}
+}
+
+var PRINT_FLAGS = []Flag{
+	{Long: "no-format", Short: "n", Help: "Prints each memo as a single line with its values tab-separated."},
+	{Long: "columns", Value: "COLUMNS", Help: "A comma separated list of the columns to print, from " + strings.Join(ALL_COLUMNS, ", ") + ", otherwise `Columns` from the config."},
+	{Long: "sort", Value: "SORT", Help: "One of title, created, updated, tag or hash, otherwise `Sort` from the config."},
+	{Long: "reverse", Short: "r", Help: "Reverses the sort."},
+	{Long: "content-lines", Value: "N", Help: "Truncates each memo's content to N lines."},
+}
+
+// Sets the options from the PRINT_FLAGS given
+func (options *PrintOptions) ApplyArgs(args *Args) {
+	options.SkipFormatting = options.SkipFormatting || args.Has("no-format")
+	options.Reverse = args.Has("reverse")
+	if args.Has("columns") {
+		columns := []string{}
+		for _, column := range strings.Split(args.Value("columns"), ",") {
+			column = strings.ToLower(strings.TrimSpace(column))
+			if !slices.Contains(ALL_COLUMNS, column) {
+				cliError(fmt.Sprintf("Unknown column '%s', expected one of %s", column, strings.Join(ALL_COLUMNS, ", ")))
+			}
+			columns = append(columns, column)
+		}
+		options.Columns = columns
+	}
+	if args.Has("sort") {
+		sort_by := strings.ToLower(strings.TrimSpace(args.Value("sort")))
+		if !slices.Contains(ALL_SORTS, sort_by) {
+			cliError(fmt.Sprintf("Unknown sort '%s', expected one of %s", sort_by, strings.Join(ALL_SORTS, ", ")))
+		}
+		options.Sort = sort_by
+	}
+	if args.Has("content-lines") {
+		lines, err := strconv.Atoi(strings.TrimSpace(args.Value("content-lines")))
+		if err != nil || lines < 1 {
+			cliError(fmt.Sprintf("Invalid number of content lines '%s'", args.Value("content-lines")))
+		}
+		options.ContentLines = lines
+	}
+}
+
+func SortHashes(memos map[HASH]*Memo, sort_by string, reverse bool) []HASH {
+	hashes := make([]HASH, 0)
+	for hash := range memos {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+
+	less := func(a *Memo, b *Memo) bool { return false }
+	switch sort_by {
+	case SORT_TITLE:
+		less = func(a *Memo, b *Memo) bool {
+			return strings.ToLower(a.Title) < strings.ToLower(b.Title)
+		}
+	case SORT_CREATED:
+		less = func(a *Memo, b *Memo) bool { return a.Created.Before(b.Created) }
+	case SORT_UPDATED:
+		less = func(a *Memo, b *Memo) bool { return a.Updated.Before(b.Updated) }
+	case SORT_TAG:
+		// Untagged memos go last
+		first_tag := func(memo *Memo) string {
+			if len(memo.Tags) == 0 {
+				return "\uffff"
+			}
+			return strings.ToLower(slices.Min(memo.Tags))
+		}
+		less = func(a *Memo, b *Memo) bool { return first_tag(a) < first_tag(b) }
+	}
+	// Stable on top of the hash order keeps ties deterministic
+	sort.SliceStable(hashes, func(i, j int) bool {
+		return less(memos[hashes[i]], memos[hashes[j]])
+	})
+
+	if reverse {
+		slices.Reverse(hashes)
+	}
+	return hashes
+}
+
+func FormatDate(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+	return date.Local().Format(DATE_FORMAT)
+}
+
+func ColumnValue(column string, hash HASH, memo *Memo) string {
+	switch column {
+	case COLUMN_HASH:
+		return hash[0:8]
+	case COLUMN_TITLE:
+		return memo.Title
+	case COLUMN_CONTENT:
+		return memo.Content
+	case COLUMN_TAGS:
+		return strings.Join(memo.Tags, ", ")
+	case COLUMN_TYPE:
+		return memo.Type
+	case COLUMN_CREATED:
+		return FormatDate(memo.Created)
+	case COLUMN_UPDATED:
+		return FormatDate(memo.Updated)
+	case COLUMN_SOURCE:
+		return StoreName(memo.Dir)
+	}
+	return ""
+}
+
+func ColumnNaturalWidth(column string, hash HASH, memo *Memo) int {
+	return int(LongestOfMultiline(ColumnValue(column, hash, memo)))
+}
+
+// Wraps a column's value to width, limiting and highlighting content as
+// options say
+func ColumnLines(column string, hash HASH, memo *Memo, width int, options *PrintOptions) []string {
+	value := ColumnValue(column, hash, memo)
+	if !COLUMN_FLEXIBLE[column] {
+		return []string{value}
+	}
+
+	lines := Chunks(value, width)
+	if column == COLUMN_CONTENT && options.Highlight {
+		for i, line := range lines {
+			lines[i] = Highlight(memo.Type, line)
+		}
+	}
+	max_lines := options.ContentLines
+	if column == COLUMN_CONTENT && max_lines > 0 && len(lines) > max_lines {
+		lines = lines[0:max_lines]
+		last := lines[max_lines-1]
+		if StringWidth(last)+StringWidth(ELLIPSIS) > width {
+			last = Truncate(last, width-StringWidth(ELLIPSIS))
+		}
+		lines[max_lines-1] = last + ELLIPSIS
+	}
+	return lines
+}
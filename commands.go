@@ -0,0 +1,459 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var COMMANDS []*Command
+
+var VAR_FLAG = Flag{
+	Long:   "var",
+	Value:  "NAME=VALUE",
+	Repeat: true,
+	Help:   "Fills in placeholder NAME without asking.",
+}
+
+var SUGGEST_FLAG = Flag{
+	Long:   "suggest",
+	Value:  "NAME=COMMAND",
+	Repeat: true,
+	Help:   "A shell command whose output lines are offered as the values for placeholder NAME.",
+}
+
+var TYPE_FLAG = Flag{
+	Long:  "type",
+	Value: "TYPE",
+	Help: "One of " + strings.Join(LanguageNames(), ", ") + ", otherwise detected from a shebang line or a fenced code block. " +
+		"It sets the editor's file extension and the highlighting in `" + APP_NAME + " " + CMD_SHOW + "`, and only sh, fish, python, javascript and ruby memos can be run.",
+}
+
+const PLACEHOLDERS_HELP = "Placeholders in the content, written {name}, {name:default}, {name|description} or {name:default|description}, are filled from (--var) or prompted for, suggesting the values used last time. `${var}`, `{{...}}` and `{1..10}` are left as they are, and other braces, such as awk's `'{print}'`, are kept by escaping them as `\\{print}`."
+
+const CLIPBOARD_HELP = "The clipboard is set with the OSC 52 terminal escape sequence and wl-copy, xclip, xsel or pbcopy when available. Set `Clipboard` in the config to \"osc52\" or a command reading from stdin to choose one."
+
+// Declared in init as the commands refer back to COMMANDS for their help
+func init() {
+	tag_list := &Command{
+		Name: CMD_LIST,
+		Help: "Lists all existing tags.",
+		Run:  func(args *Args) { ShowTags(config) },
+	}
+
+	COMMANDS = []*Command{
+		{
+			Name: CMD_ADD,
+			Args: []Arg{{Name: "TITLE"}, {Name: "CONTENTS", Optional: true}},
+			Flags: []Flag{
+				{Long: "tags", Short: "t", Value: "TAGS", Help: "A comma separated list of tags."},
+				TYPE_FLAG,
+				{Long: "no-run", Help: fmt.Sprintf("Stops the memo being used with `%s %s`.", APP_NAME, CMD_RUN)},
+				SUGGEST_FLAG,
+				NEW_MEMO_STORE_FLAGS[0],
+				NEW_MEMO_STORE_FLAGS[1],
+			},
+			Help: "Creates a new memo. Inside a project with a " + LOCAL_DIR + " directory it goes in the store set by `DefaultStore`, global unless set. If CONTENTS is `-`, or not given while stdin is piped, it is read from stdin. If no CONTENTS is given, the system text editor will be opened for input: `Editor` from the config, $VISUAL, $EDITOR, `DefaultEditor` from the config, vi or nano. Quitting the editor with an error or an empty buffer cancels the memo.",
+			Run:  func(args *Args) { AddMemo(ui, config, args) },
+			Examples: []Example{
+				{Command: `memo add "Kill process using port" 'kill $(lsof -t -i:{port})' -t network,unix`, Help: "Add a memo with a placeholder and tags"},
+				{Command: `git log --oneline | memo add "Recent commits" -`, Help: "Add a memo read from stdin"},
+				{Command: `memo add "Deploy steps"`, Help: "Write a memo in the editor"},
+				{Command: `memo add --local "Build" 'make build'`, Help: "Add a memo to the project's store"},
+			},
+			Exits: PROMPT_EXITS,
+		},
+		{
+			Name: CMD_CAPTURE,
+			Flags: []Flag{
+				{Long: "title", Value: "TITLE", Help: "The memo's title, which is asked for if not given."},
+				{Long: "tag", Short: "t", Value: "TAG", Repeat: true, Help: "Tags the memo."},
+				NEW_MEMO_STORE_FLAGS[0],
+				NEW_MEMO_STORE_FLAGS[1],
+			},
+			Help: fmt.Sprintf("Creates a memo from a shell command, read from stdin or otherwise the last command in $HISTFILE (bash or zsh). See `%s %s` for saving the previous command with a key press.", APP_NAME, CMD_SHELL_INIT),
+			Run:  func(args *Args) { CaptureMemo(ui, config, args) },
+			Examples: []Example{
+				{Command: `memo capture --title "Disk usage" -t unix`, Help: "Save the last command in the history file"},
+				{Command: `echo 'du -sh * | sort -h' | memo capture`, Help: "Save a command from stdin, asking for the title"},
+			},
+			Exits: []Exit{NO_INPUT_EXIT},
+		},
+		{
+			Name: CMD_COMPLETION,
+			Args: []Arg{{Name: "SHELL"}},
+			Help: fmt.Sprintf("Prints the tab completion script for SHELL, one of bash, zsh or fish. It completes commands, flags, memo titles and hashes, tags, types and columns. Load it with `eval \"$(%s %s bash)\"` in ~/.bashrc, likewise for zsh, or `%s %s fish | source` for fish.", APP_NAME, CMD_COMPLETION, APP_NAME, CMD_COMPLETION),
+			Examples: []Example{
+				{Command: `memo completion fish > ~/.config/fish/completions/memo.fish`, Help: "Install the completion for fish"},
+			},
+			Run: func(args *Args) { PrintCompletionScript(args) },
+		},
+		{
+			Name:     CMD_COMPLETE,
+			Help:     "Prints the completions for the words given, the last being the one being completed, as lines of the value and a tab separated description. Used by the completion scripts.",
+			Hidden:   true,
+			Unparsed: true,
+			Run:      func(args *Args) { PrintCompletions(args) },
+		},
+		{
+			Name: CMD_CONFIG,
+			Help: fmt.Sprintf("Shows and changes the settings in memo.conf. Each comes from its MEMO_<SETTING> environment variable, e.g. MEMO_SAVES_DIR, then the project's %s/memo.conf when in one, then the user's config file, then its default. Run `%s %s %s` for them all.", LOCAL_DIR, APP_NAME, CMD_CONFIG, CMD_LIST),
+			Subcommands: []*Command{
+				{
+					Name:    CMD_LIST,
+					Aliases: []string{"list"},
+					Flags: []Flag{
+						{Long: "no-format", Short: "n", Help: "Prints each setting as a single line with its values tab-separated."},
+					},
+					Help: "Lists every setting with its value and where the value comes from: default, file, local file or env.",
+					Examples: []Example{
+						{Command: `memo config ls`, Help: "List the settings"},
+					},
+					Run: func(args *Args) { ListSettings(args) },
+				},
+				{
+					Name: CMD_CONFIG_GET,
+					Args: []Arg{{Name: "SETTING"}},
+					Flags: []Flag{
+						{Long: "source", Short: "s", Help: "Also prints where the value comes from, after a tab."},
+					},
+					Help: "Prints the value of SETTING, ignoring case.",
+					Examples: []Example{
+						{Command: `memo config get SavesDir`, Help: "Print where memos are saved"},
+					},
+					Run: func(args *Args) { GetSetting(args) },
+				},
+				{
+					Name:  CMD_CONFIG_SET,
+					Args:  []Arg{{Name: "SETTING"}, {Name: "VALUE"}},
+					Flags: []Flag{CONFIG_LOCAL_FLAG},
+					Help:  "Checks VALUE and saves it to the config file. SETTING is matched ignoring case and lists are comma separated. " + SettingsHelp(),
+					Examples: []Example{
+						{Command: `memo config set Sort title`, Help: "List memos by title"},
+						{Command: `memo config set Columns hash,title,tags`, Help: "Choose the columns printed"},
+						{Command: `memo config set ColumnWidths title=30,content=50`, Help: "Limit the width of columns"},
+						{Command: `memo config set --local DefaultStore local`, Help: "Save new memos in the project"},
+					},
+					Run: func(args *Args) { SetSetting(args) },
+				},
+				{
+					Name:  CMD_CONFIG_UNSET,
+					Args:  []Arg{{Name: "SETTING"}},
+					Flags: []Flag{CONFIG_LOCAL_FLAG},
+					Help:  "Removes SETTING from the config file, going back to its default.",
+					Examples: []Example{
+						{Command: `memo config unset Editor`, Help: "Use $VISUAL or $EDITOR again"},
+					},
+					Run: func(args *Args) { UnsetSetting(args) },
+				},
+				{
+					Name:  CMD_CONFIG_PATH,
+					Flags: []Flag{CONFIG_LOCAL_FLAG},
+					Help:  "Prints the path of the config file, $MEMO_CONF_PATH or memo.conf in the user config directory.",
+					Examples: []Example{
+						{Command: `cat "$(memo config path)"`, Help: "Print the config file"},
+					},
+					Run: func(args *Args) { PrintConfigPath(args) },
+				},
+				{
+					Name:  CMD_EDIT,
+					Flags: []Flag{CONFIG_LOCAL_FLAG},
+					Help:  "Opens the config file in the editor, reopening it until it's valid.",
+					Examples: []Example{
+						{Command: `memo config edit`, Help: "Edit the config"},
+					},
+					Exits: PROMPT_EXITS,
+					Run:   func(args *Args) { EditConfig(ui, config, args) },
+				},
+			},
+		},
+		{
+			Name: CMD_COPY,
+			Args: []Arg{{Name: "IDENTIFIER"}},
+			Flags: []Flag{
+				{Long: "fill", Short: "f", Help: "Fills in the memo's placeholders first."},
+				VAR_FLAG,
+			},
+			Help: "Copies a memo's content to the clipboard. " + IDENTIFIER_HELP + " " + CLIPBOARD_HELP,
+			Run:  func(args *Args) { CopyMemo(ui, config, args) },
+			Examples: []Example{
+				{Command: `memo copy "Kill process using port" --var port=3001`, Help: "Copy a memo with its placeholder filled in"},
+			},
+			Exits: []Exit{NO_INPUT_EXIT},
+		},
+		{
+			Name: CMD_EDIT,
+			Args: []Arg{{Name: "IDENTIFIER"}, {Name: "CONTENTS", Optional: true}},
+			Flags: []Flag{
+				{Long: "accept", Short: "a", Help: "Accepts the changes without showing a diff to confirm."},
+				TYPE_FLAG,
+				{Long: "no-run", Help: fmt.Sprintf("Stops the memo being used with `%s %s`.", APP_NAME, CMD_RUN)},
+				{Long: "runnable", Help: fmt.Sprintf("Allows the memo to be used with `%s %s` again.", APP_NAME, CMD_RUN)},
+				{Long: SUGGEST_FLAG.Long, Value: SUGGEST_FLAG.Value, Repeat: true, Help: SUGGEST_FLAG.Help + " An empty COMMAND removes it."},
+			},
+			Help: "Edits a memo. " + IDENTIFIER_HELP + " If CONTENTS is `-`, or not given while stdin is piped, it is read from stdin. If no CONTENTS is given, the system text editor will be opened with the memo's title, tags, type and other settings in a header above its content, all of which can be changed. The editor is opened again if the header is invalid, and emptying the document cancels the edit. Settings given without CONTENTS are changed without opening the editor.",
+			Run:  func(args *Args) { EditMemo(ui, config, args) },
+			Examples: []Example{
+				{Command: `memo edit 1a2b3c4d`, Help: "Edit a memo and its settings in the editor"},
+				{Command: `memo edit "Deploy steps" --type sh --no-run`, Help: "Change settings without opening the editor"},
+				{Command: `pbpaste | memo edit "Deploy steps" - -a`, Help: "Replace the content from stdin without confirming"},
+			},
+			Exits: PROMPT_EXITS,
+		},
+		{
+			Name: CMD_LIST,
+			Flags: append([]Flag{
+				{Long: "grouped", Short: "g", Help: "Prints memos grouped under a heading per tag, packed into columns like a cheatsheet."},
+				{Long: "tag", Short: "t", Value: "TAG", Repeat: true, Help: "Only prints memos with ANY of the given tags."},
+				{Long: "query", Short: "q", Value: "QUERY", Help: "Only prints memos matching QUERY. " + QUERY_HELP},
+				{Long: "ids", Help: "Only prints the memos' hashes, one per line, e.g. to pipe into a command taking `-` as its IDENTIFIER."},
+				STORE_FLAGS[0],
+				STORE_FLAGS[1],
+			}, PRINT_FLAGS...),
+			Help: fmt.Sprintf("Prints memos, from both the global store and the project's when inside a project with a %s directory, which adds a source column. The default columns, sort and format, and maximum column widths, are the `Columns`, `Sort`, `Format` and `ColumnWidths` settings, e.g. `%s %s %s ColumnWidths title=30`.", LOCAL_DIR, APP_NAME, CMD_CONFIG, CMD_CONFIG_SET),
+			Run:  func(args *Args) { ShowMemos(ui, config, args) },
+			Examples: []Example{
+				{Command: `memo ls -t network --columns hash,title --sort title`, Help: "List hashes and titles of memos tagged network"},
+				{Command: `memo ls -g`, Help: "Print a cheatsheet grouped by tag"},
+				{Command: `memo ls --local`, Help: "List only the project's memos"},
+			},
+		},
+		{
+			Name: CMD_MOVE,
+			Args: []Arg{{Name: "IDENTIFIER", Optional: true}},
+			Flags: append(append([]Flag{
+				{Long: "to", Value: "NOTEBOOK", Help: "The notebook the memos are moved to."},
+			}, SELECT_FLAGS...), BULK_FLAGS...),
+			Help: fmt.Sprintf("Moves a memo, or many, to another notebook, keeping their hashes. %s %s A memo can't be moved to a notebook with one of the same title. See `%s %s %s`.", IDENTIFIER_HELP, BULK_HELP, APP_NAME, CMD_NOTEBOOK, CMD_LIST),
+			Run:  func(args *Args) { MoveMemo(ui, config, args) },
+			Examples: []Example{
+				{Command: `memo mv "Deploy steps" --to work`, Help: "Move a memo to the work notebook"},
+				{Command: `memo --notebook work mv -t home --to personal -y`, Help: "Move the memos tagged home from work to personal"},
+			},
+			Exits: PROMPT_EXITS,
+		},
+		{
+			Name: CMD_NOTEBOOK,
+			Help: fmt.Sprintf("Lists, creates and removes notebooks, separate collections of memos. The default notebook is saved in `SavesDir` and the others are listed in the `Notebooks` setting. The one used as the global store is chosen by (--notebook), then $MEMO_NOTEBOOK, then the `Notebook` setting set by `%s %s %s`.", APP_NAME, CMD_NOTEBOOK, CMD_NOTEBOOK_DEFAULT),
+			Subcommands: []*Command{
+				{
+					Name:    CMD_LIST,
+					Aliases: []string{"list"},
+					Flags: []Flag{
+						{Long: "no-format", Short: "n", Help: "Prints each notebook as a single line with its name, number of memos and directory tab-separated."},
+					},
+					Help: "Lists the notebooks with their number of memos and directory, marking the one in use with *.",
+					Examples: []Example{
+						{Command: `memo notebook ls`, Help: "List the notebooks"},
+					},
+					Run: func(args *Args) { ListNotebooks(config, args) },
+				},
+				{
+					Name: CMD_NOTEBOOK_CREATE,
+					Args: []Arg{{Name: "NAME"}},
+					Flags: []Flag{
+						{Long: "dir", Value: "DIR", Help: "Saves the notebook's memos in DIR, e.g. a synced folder, rather than next to the config."},
+					},
+					Help: "Creates a notebook and adds it to the `Notebooks` setting. NAME can have letters, digits, '-', '_' and '.'. An existing DIR keeps any memos already in it.",
+					Examples: []Example{
+						{Command: `memo notebook create work`, Help: "Create a notebook"},
+						{Command: `memo notebook create personal --dir ~/Sync/memos`, Help: "Create a notebook in a synced folder"},
+					},
+					Run: func(args *Args) { CreateNotebook(config, args) },
+				},
+				{
+					Name: CMD_REMOVE,
+					Args: []Arg{{Name: "NOTEBOOK"}},
+					Flags: []Flag{
+						{Long: "yes", Short: "y", Help: "Deletes the notebook's memos without asking to confirm."},
+						{Long: "keep-memos", Help: "Only removes the notebook from the config, leaving its memos where they are."},
+					},
+					Help: fmt.Sprintf("Removes a notebook and deletes its memos, after confirming. A notebook created with (--dir) needs (--keep-memos), as its directory isn't memo's to delete. The default notebook can't be removed, nor the one set by `%s %s %s`.", APP_NAME, CMD_NOTEBOOK, CMD_NOTEBOOK_DEFAULT),
+					Examples: []Example{
+						{Command: `memo notebook rm oncall`, Help: "Remove a notebook and its memos"},
+						{Command: `memo notebook rm personal --keep-memos`, Help: "Forget a notebook but keep its files"},
+					},
+					Exits: PROMPT_EXITS,
+					Run:   func(args *Args) { RemoveNotebook(ui, config, args) },
+				},
+				{
+					Name: CMD_NOTEBOOK_DEFAULT,
+					Args: []Arg{{Name: "NOTEBOOK", Optional: true}},
+					Help: fmt.Sprintf("Prints the notebook used without (--notebook), or sets it to NOTEBOOK. It is the `Notebook` setting, which $MEMO_NOTEBOOK overrides, and `%s` is the one in `SavesDir`.", NOTEBOOK_DEFAULT),
+					Examples: []Example{
+						{Command: `memo notebook default work`, Help: "Use the work notebook from now on"},
+						{Command: `memo notebook default default`, Help: "Go back to the default notebook"},
+					},
+					Run: func(args *Args) { DefaultNotebook(config, args) },
+				},
+			},
+		},
+		{
+			Name: CMD_PICK,
+			Flags: []Flag{
+				{Long: "query", Short: "q", Value: "QUERY", Help: "Starts with QUERY typed in."},
+				{Long: "tag", Short: "t", Value: "TAG", Repeat: true, Help: "Only lists memos with ANY of the given tags."},
+				{Long: "print-id", Short: "i", Help: "Prints the chosen memo's hash instead of its content."},
+				{Long: "copy", Help: "Also copies the content to the clipboard."},
+				VAR_FLAG,
+			},
+			Help: "Opens a fuzzy-select list on the terminal and prints the chosen memo's content, with its placeholders filled in. The list is drawn on the terminal rather than stdout so the result can be used in `$(memo pick)`.",
+			Run:  func(args *Args) { PickMemo(ui, config, args) },
+			Examples: []Example{
+				{Command: `eval "$(memo pick -q docker)"`, Help: "Pick a memo and run it in the current shell"},
+				{Command: `memo show "$(memo pick -i)"`, Help: "Pick a memo by hash"},
+			},
+			Exits: PROMPT_EXITS,
+		},
+		{
+			Name:  CMD_REMOVE,
+			Args:  []Arg{{Name: "IDENTIFIER", Optional: true}},
+			Flags: append(append([]Flag{}, SELECT_FLAGS...), BULK_FLAGS...),
+			Help:  "Deletes a memo, or many. " + IDENTIFIER_HELP + " " + BULK_HELP,
+			Run:   func(args *Args) { RemoveMemo(ui, config, args) },
+			Examples: []Example{
+				{Command: `memo rm 1a2b3c4d`, Help: "Delete a memo by hash"},
+				{Command: `memo rm --tag obsolete`, Help: "Delete every memo tagged obsolete, after confirming"},
+				{Command: `memo rm -q 'type:sql' --dry-run`, Help: "See which memos would be deleted"},
+			},
+			Exits: PROMPT_EXITS,
+		},
+		{
+			Name: CMD_RUN,
+			Args: []Arg{{Name: "IDENTIFIER"}},
+			Flags: []Flag{
+				{Long: "yes", Short: "y", Help: "Runs the command without asking to confirm it."},
+				{Long: "step", Short: "s", Help: "Runs the memo as a runbook, one line at a time with the comments above each line as its description, asking whether to run, skip, retry or abort each step and printing a summary at the end. With (-y/--yes) every step is run, stopping at the first failure."},
+				VAR_FLAG,
+			},
+			Extra: "ARGS",
+			Help:  "Runs a memo's content as a shell command, or with the interpreter for its type, and exits with the command's exit code. " + IDENTIFIER_HELP + " ARGS are quoted and added to the end of the command. The shell is `Shell` from the config, otherwise $SHELL or /bin/sh. " + PLACEHOLDERS_HELP,
+			Run:   func(args *Args) { RunMemo(ui, config, args) },
+			Examples: []Example{
+				{Command: `memo run "Kill process using port" --var port=3001 -y`, Help: "Run a memo without any prompts"},
+				{Command: `memo run "List files" -- -la /tmp`, Help: "Run a memo with extra arguments"},
+				{Command: `memo run "Deploy steps" --step`, Help: "Run a memo one line at a time"},
+			},
+			Exits: append(slices.Clone(PROMPT_EXITS), Exit{
+				Code: "N",
+				Help: "Otherwise the command's own exit status, or that of the first failed step.",
+			}),
+		},
+		{
+			Name: CMD_SEARCH,
+			Args: []Arg{{Name: "SEARCH_TERM", Optional: true, Variadic: true}},
+			Flags: append([]Flag{
+				{Long: "title", Short: "t", Help: "Only searches memo titles."},
+				{Long: "content", Short: "c", Help: "Only searches memo contents."},
+				STORE_FLAGS[0],
+				STORE_FLAGS[1],
+			}, PRINT_FLAGS...),
+			Help: "Searches memos for SEARCH_TERM, ignoring case, in the global store and the project's.",
+			Run:  func(args *Args) { SearchMemos(ui, config, args) },
+			Examples: []Example{
+				{Command: `memo search -t docker`, Help: "Find memos with docker in the title"},
+			},
+		},
+		{
+			Name: CMD_SHELL_INIT,
+			Args: []Arg{{Name: "SHELL"}},
+			Help: fmt.Sprintf("Prints shell code for SHELL, one of bash, zsh or fish, defining `%s-%s`, which saves the command run before it as a memo, and binding Ctrl-X m to save the last command. Load it with `eval \"$(%s %s bash)\"` in ~/.bashrc, likewise for zsh, or `%s %s fish | source` for fish.", APP_NAME, CMD_CAPTURE, APP_NAME, CMD_SHELL_INIT, APP_NAME, CMD_SHELL_INIT),
+			Run:  func(args *Args) { PrintShellInit(args) },
+			Examples: []Example{
+				{Command: `eval "$(memo shell-init zsh)"`, Help: "Load the integration in ~/.zshrc"},
+			},
+		},
+		{
+			Name: CMD_SHOW,
+			Args: []Arg{{Name: "IDENTIFIER"}},
+			Flags: append([]Flag{
+				{Long: "fill", Short: "f", Help: "Fills in the memo's placeholders first."},
+				VAR_FLAG,
+				{Long: "copy", Help: "Also copies the content to the clipboard."},
+			}, PRINT_FLAGS...),
+			Help: "Prints a memo. " + IDENTIFIER_HELP + " Content is syntax highlighted by memo type, as set by `Color` in the config.",
+			Run:  func(args *Args) { ShowMemo(ui, config, args) },
+			Examples: []Example{
+				{Command: `memo show "Kill process using port" -f --var port=3001 -n`, Help: "Print a memo's content with its placeholder filled in"},
+			},
+			Exits: []Exit{NO_INPUT_EXIT},
+		},
+		{
+			Name:  CMD_UI,
+			Help:  "Opens a full-screen browser. Typing filters the memos, arrow keys move the selection and cycle the tag filter. Ctrl-E edits, Ctrl-T tags, Ctrl-D deletes and Ctrl-Y copies the selected memo. Ctrl-G toggles the grouped cheatsheet view. Esc quits.",
+			Run:   func(args *Args) { BrowseMemos(ui, config) },
+			Exits: []Exit{NO_INPUT_EXIT},
+		},
+		{
+			Name: CMD_TAG,
+			Help: "Adds, lists and removes tags.",
+			Subcommands: []*Command{
+				{
+					Name:  CMD_ADD,
+					Args:  []Arg{{Name: "IDENTIFIER", Optional: true}, {Name: "TAG"}},
+					Flags: append(append([]Flag{}, SELECT_FLAGS...), BULK_FLAGS...),
+					Help:  "Adds a tag to a memo, or many. " + IDENTIFIER_HELP + " " + BULK_HELP,
+					Run:   func(args *Args) { AddTag(ui, config, args) },
+					Examples: []Example{
+						{Command: `memo tag add 1a2b3c4d network`, Help: "Tag a memo"},
+						{Command: `memo tag add --query 'content:docker' docker`, Help: "Tag every memo mentioning docker"},
+						{Command: `memo ls --ids -t k8s | memo tag add - ops`, Help: "Tag the memos listed on stdin"},
+					},
+					Exits: PROMPT_EXITS,
+				},
+				tag_list,
+				{
+					Name:  CMD_REMOVE,
+					Args:  []Arg{{Name: "IDENTIFIER", Optional: true}, {Name: "TAG"}},
+					Flags: append(append([]Flag{}, SELECT_FLAGS...), BULK_FLAGS...),
+					Help:  "Removes a tag from a memo, or many. " + IDENTIFIER_HELP + " " + BULK_HELP,
+					Run:   func(args *Args) { RemoveTag(ui, config, args) },
+					Examples: []Example{
+						{Command: `memo tag rm --tag old old -y`, Help: "Remove a tag from every memo without confirming"},
+					},
+					Exits: PROMPT_EXITS,
+				},
+			},
+		},
+		{
+			Name: CMD_TAGS,
+			Help: fmt.Sprintf("Alias for `%s %s %s`.", APP_NAME, CMD_TAG, CMD_LIST),
+			Run:  tag_list.Run,
+		},
+		{
+			Name:    CMD_VERSION,
+			Aliases: []string{CMD_VERSION_LONG, CMD_VERSION_SHORT},
+			Help:    "Prints this current version.",
+			Run:     func(args *Args) { PrintVersion() },
+		},
+		{
+			Name:    CMD_HELP,
+			Aliases: []string{HELP, HELP_SHORT},
+			Args:    []Arg{{Name: "COMMAND", Optional: true, Variadic: true}},
+			Help:    fmt.Sprintf("Prints the usage, options, examples and exit statuses of COMMAND, which may be a command with its subcommand such as `%s %s %s %s`, or lists all commands. `%s <COMMAND> %s` does the same.", APP_NAME, CMD_HELP, CMD_TAG, CMD_ADD, APP_NAME, HELP),
+			Examples: []Example{
+				{Command: `memo help run`, Help: "Show the help for run"},
+			},
+			Run: func(args *Args) { ShowHelp(args) },
+		},
+		{
+			Name: CMD_GEN_MAN,
+			Help: fmt.Sprintf("Prints a man page for %s, generated from the same descriptions as the help.", APP_NAME),
+			Examples: []Example{
+				{Command: `memo gen-man > ~/.local/share/man/man1/memo.1`, Help: "Install the man page for the current user"},
+			},
+			Run: func(args *Args) { GenerateManPage(args) },
+		},
+		{
+			Name: CMD_GEN_DOCS,
+			Flags: []Flag{
+				{Long: "markdown", Help: "Prints the reference as Markdown, the only format so far."},
+			},
+			Help: "Prints a reference of all commands, generated from the same descriptions as the help.",
+			Examples: []Example{
+				{Command: `memo gen-docs --markdown > COMMANDS.md`, Help: "Write the command reference"},
+			},
+			Run: func(args *Args) { GenerateDocs(args) },
+		},
+	}
+}
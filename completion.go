@@ -0,0 +1,370 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Completion scripts hand the words typed so far, the last being the one
+// being completed, to the hidden `memo __complete`, which prints one
+// candidate per line as `<value>\t<description>`.
+
+type Candidate struct {
+	Value       string
+	Description string
+}
+
+func CompleteWords(words []string) []Candidate {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	current := strings.TrimLeft(words[len(words)-1], `"'`)
+	words = words[:len(words)-1]
+
+	commands := COMMANDS
+	var command *Command
+	for len(words) > 0 {
+		// Global flags can come before each command, as in Dispatch
+		name, _, has_value := strings.Cut(strings.TrimPrefix(words[0], "--"), "=")
+		if global := (&Command{}).LongFlag(name); global != nil && strings.HasPrefix(words[0], "--") {
+			words = words[1:]
+			if global.Value != "" && !has_value {
+				if len(words) == 0 {
+					return CompleteValue(&Command{}, global.Value, "", current, nil)
+				}
+				words = words[1:]
+			}
+			continue
+		}
+		command = FindCommand(commands, words[0])
+		if command == nil {
+			return nil
+		}
+		words = words[1:]
+		if len(command.Subcommands) == 0 {
+			break
+		}
+		commands = command.Subcommands
+		command = nil
+	}
+	if command == nil {
+		if name, value, ok := strings.Cut(strings.TrimPrefix(current, "--"), "="); ok && strings.HasPrefix(current, "--") {
+			if global := (&Command{}).LongFlag(name); global != nil && global.Value != "" {
+				return CompleteValue(&Command{}, global.Value, "--"+name+"=", value, nil)
+			}
+			return nil
+		}
+		candidates := CompleteCommands(commands, current)
+		if strings.HasPrefix(current, "-") {
+			candidates = append(candidates, CompleteFlagList(GLOBAL_FLAGS, current)...)
+		}
+		return candidates
+	}
+
+	// Find the positional arguments given so far, and whether the word
+	// being completed is a flag's value
+	positional := []string{}
+	var value_of *Flag
+	after_dashes := false
+	for _, word := range words {
+		switch {
+		case value_of != nil:
+			value_of = nil
+		case after_dashes || word == STDIN_ARG || !strings.HasPrefix(word, "-"):
+			positional = append(positional, strings.Trim(word, `"'`))
+		case word == "--":
+			after_dashes = true
+		case strings.HasPrefix(word, "--"):
+			if flag := command.LongFlag(word[2:]); flag != nil && flag.Value != "" {
+				value_of = flag
+			}
+		default:
+			// Combined switches, where a flag with a value takes the rest
+			for j, short := range word[1:] {
+				if flag := command.ShortFlag(string(short)); flag != nil && flag.Value != "" {
+					if j == len(word)-2 {
+						value_of = flag
+					}
+					break
+				}
+			}
+		}
+	}
+
+	if value_of != nil {
+		return CompleteValue(command, value_of.Value, "", current, positional)
+	}
+	if !after_dashes && strings.HasPrefix(current, "--") {
+		if name, value, ok := strings.Cut(current[2:], "="); ok {
+			if flag := command.LongFlag(name); flag != nil && flag.Value != "" {
+				return CompleteValue(command, flag.Value, "--"+name+"=", value, positional)
+			}
+			return nil
+		}
+	}
+	if !after_dashes && strings.HasPrefix(current, "-") {
+		return CompleteFlags(command, current)
+	}
+	if after_dashes && command.Extra != "" {
+		return nil
+	}
+
+	if len(command.Args) == 0 {
+		return nil
+	}
+	arg := command.Args[len(command.Args)-1]
+	if len(positional) < len(command.Args) {
+		arg = command.Args[len(positional)]
+	} else if !arg.Variadic {
+		return nil
+	}
+	return CompleteValue(command, arg.Name, "", current, positional)
+}
+
+func CompleteCommands(commands []*Command, current string) []Candidate {
+	candidates := []Candidate{}
+	for _, command := range commands {
+		if command.Hidden {
+			continue
+		}
+		names := []string{command.Name}
+		if strings.HasPrefix(current, "-") {
+			names = command.Aliases
+		}
+		for _, name := range names {
+			if strings.HasPrefix(name, current) {
+				candidates = append(candidates, Candidate{Value: name, Description: command.Summary()})
+			}
+		}
+	}
+	return candidates
+}
+
+// The command's flags, --help and GLOBAL_FLAGS
+func CompleteFlags(command *Command, current string) []Candidate {
+	help := Flag{Long: "help", Help: "Prints the help for this command."}
+	return CompleteFlagList(slices.Concat(command.Flags, []Flag{help}, GLOBAL_FLAGS), current)
+}
+
+func CompleteFlagList(flags []Flag, current string) []Candidate {
+	candidates := []Candidate{}
+	for _, flag := range flags {
+		value := "--" + flag.Long
+		if flag.Value != "" {
+			value += "="
+		}
+		if strings.HasPrefix(value, current) {
+			summary, _, _ := strings.Cut(flag.Help, ". ")
+			candidates = append(candidates, Candidate{Value: value, Description: strings.TrimSuffix(summary, ".")})
+		}
+	}
+	return candidates
+}
+
+// Candidates for an argument or flag value by its declared name, each
+// starting with prefix. Values that aren't known, like content, get none.
+func CompleteValue(command *Command, name string, prefix string, current string, positional []string) []Candidate {
+	candidates := []Candidate{}
+	add := func(value string, description string) {
+		if strings.HasPrefix(value, current) {
+			candidates = append(candidates, Candidate{Value: prefix + value, Description: description})
+		}
+	}
+
+	switch name {
+	case "IDENTIFIER":
+		memos := LoadAllMemos(config)
+		for hash, memo := range memos {
+			add(memo.Title, hash[0:8])
+			// Hashes only once some of one is typed, to not list every memo twice
+			if current != "" {
+				add(hash[0:8], memo.Title)
+			}
+		}
+	case "TAG":
+		tags := AllTags()
+		// `tag rm` offers the memo's own tags
+		if command.Name == CMD_REMOVE && len(positional) > 0 {
+			memos := LoadAllMemos(config)
+			if hash, err := FindMemo(memos, positional[0]); err == nil {
+				tags = memos[hash].Tags
+			}
+		}
+		for _, tag := range tags {
+			add(tag, "")
+		}
+	case "TAGS":
+		return CompleteList(AllTags(), prefix, current)
+	case "TYPE":
+		for _, language := range LanguageNames() {
+			add(language, "")
+		}
+	case "COLUMNS":
+		return CompleteList(ALL_COLUMNS, prefix, current)
+	case "SORT":
+		for _, sort_by := range ALL_SORTS {
+			add(sort_by, "")
+		}
+	case "SETTING":
+		// Settings are matched ignoring case
+		for _, setting := range SETTINGS {
+			if strings.HasPrefix(strings.ToLower(setting.Key), strings.ToLower(current)) {
+				candidates = append(candidates, Candidate{Value: prefix + setting.Key, Description: setting.Help})
+			}
+		}
+	case "VALUE":
+		// `config set` offers the setting's choices
+		if len(positional) == 0 {
+			break
+		}
+		if setting := FindSetting(positional[0]); setting != nil && setting.Kind == SETTING_LIST {
+			return CompleteList(setting.Choices, prefix, current)
+		} else if setting != nil {
+			for _, choice := range setting.Choices {
+				add(choice, "")
+			}
+		}
+	case "NOTEBOOK":
+		for _, name := range NotebookNames(config) {
+			dir, _ := NotebookDir(config, name)
+			add(name, dir)
+		}
+	case "SHELL":
+		for _, shell := range []string{SHELL_BASH, SHELL_ZSH, SHELL_FISH} {
+			add(shell, "")
+		}
+	case "COMMAND":
+		commands := COMMANDS
+		for _, word := range positional {
+			parent := FindCommand(commands, word)
+			if parent == nil || len(parent.Subcommands) == 0 {
+				return candidates
+			}
+			commands = parent.Subcommands
+		}
+		for _, candidate := range CompleteCommands(commands, current) {
+			candidates = append(candidates, Candidate{Value: prefix + candidate.Value, Description: candidate.Description})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Value < candidates[j].Value })
+	return candidates
+}
+
+// Completes the last value of a comma separated list, leaving out those
+// already given
+func CompleteList(values []string, prefix string, current string) []Candidate {
+	done, last := "", current
+	if i := strings.LastIndex(current, ","); i >= 0 {
+		done, last = current[:i+1], current[i+1:]
+	}
+	candidates := []Candidate{}
+	for _, value := range values {
+		if strings.HasPrefix(value, last) && !slices.Contains(strings.Split(done, ","), value) {
+			candidates = append(candidates, Candidate{Value: prefix + done + value})
+		}
+	}
+	return candidates
+}
+
+// Every tag in use, sorted
+func AllTags() []string {
+	tags := []string{}
+	for _, memo := range LoadAllMemos(config) {
+		for _, tag := range memo.Tags {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// `memo __complete <WORDS>...`
+func PrintCompletions(args *Args) {
+	for _, candidate := range CompleteWords(args.Positional) {
+		if candidate.Description == "" {
+			fmt.Println(candidate.Value)
+		} else {
+			fmt.Printf("%s\t%s\n", candidate.Value, candidate.Description)
+		}
+	}
+}
+
+func PrintCompletionScript(args *Args) {
+	script := ""
+	switch args.Arg(0) {
+	case SHELL_BASH:
+		script = COMPLETION_BASH
+	case SHELL_ZSH:
+		script = COMPLETION_ZSH
+	case SHELL_FISH:
+		script = COMPLETION_FISH
+	default:
+		cliError(fmt.Sprintf("Unknown shell '%s', expected one of %s, %s or %s", args.Arg(0), SHELL_BASH, SHELL_ZSH, SHELL_FISH))
+	}
+	os.Stdout.WriteString(script)
+}
+
+// bash splits words on `=` and `:` unless bash-completion's
+// _get_comp_words_by_ref is there to join them back
+const COMPLETION_BASH = `# memo completion. Add to ~/.bashrc:
+#     eval "$(memo completion bash)"
+_memo() {
+    local cur words cword
+    if declare -F _get_comp_words_by_ref >/dev/null; then
+        _get_comp_words_by_ref -n =: cur words cword
+    else
+        cur=${COMP_WORDS[COMP_CWORD]}
+        words=("${COMP_WORDS[@]}")
+        cword=$COMP_CWORD
+    fi
+    local IFS=$'\n' candidate
+    COMPREPLY=()
+    for candidate in $(memo __complete "${words[@]:1:cword}" 2>/dev/null); do
+        candidate=${candidate%%$'\t'*}
+        if [[ $candidate == *= ]]; then
+            COMPREPLY+=("$candidate")
+        else
+            COMPREPLY+=("$(printf '%q' "$candidate")")
+        fi
+    done
+    [[ ${#COMPREPLY[@]} == 1 && ${COMPREPLY[0]} == *= ]] && compopt -o nospace
+}
+complete -F _memo memo
+`
+
+const COMPLETION_ZSH = `#compdef memo
+# memo completion. Add to ~/.zshrc after compinit:
+#     eval "$(memo completion zsh)"
+_memo() {
+    local -a candidates assignments
+    local line value
+    for line in "${(@f)$(memo __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}"; do
+        [[ -z $line ]] && continue
+        value=${line%%$'\t'*}
+        value=${value//:/\\:}
+        [[ $line == *$'\t'* ]] && value+=":${line#*$'\t'}"
+        # --flag= is followed by its value rather than a space
+        if [[ ${line%%$'\t'*} == *= ]]; then
+            assignments+=("$value")
+        else
+            candidates+=("$value")
+        fi
+    done
+    _describe -t memo 'memo' candidates -- assignments -S ''
+}
+compdef _memo memo
+`
+
+const COMPLETION_FISH = `# memo completion. Add to ~/.config/fish/config.fish:
+#     memo completion fish | source
+function __memo_complete
+    set -l tokens (commandline -opc) (commandline -ct)
+    memo __complete $tokens[2..-1] 2>/dev/null
+end
+complete -c memo -f -a '(__memo_complete)'
+`
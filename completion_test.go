@@ -0,0 +1,41 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCompleteWords(t *testing.T) {
+	tests := []struct {
+		words []string
+		want  []string
+	}{
+		{[]string{"--q"}, []string{"--quiet"}},
+		{[]string{"--non"}, []string{"--non-interactive"}},
+		{[]string{"ls", "--q"}, []string{"--query=", "--quiet"}},
+		// Global flags before the command are skipped, with their value
+		{[]string{"--quiet", "ls", "--no-f"}, []string{"--no-format"}},
+		{[]string{"--notebook", "work", "ls", "--so"}, []string{"--sort="}},
+		{[]string{"--notebook=work", "ls", "--sort", "t"}, []string{"tag", "title"}},
+		{[]string{"ls", "--sort=t"}, []string{"--sort=tag", "--sort=title"}},
+		{[]string{"--unknown", "ls", "--q"}, nil},
+	}
+	for _, test := range tests {
+		got := []string{}
+		for _, candidate := range CompleteWords(test.words) {
+			got = append(got, candidate.Value)
+		}
+		if test.want == nil && len(got) > 0 || test.want != nil && !slices.Equal(got, test.want) {
+			t.Errorf("CompleteWords(%q) = %q, want %q", test.words, got, test.want)
+		}
+	}
+}
+
+func TestCompleteFlagsKeepsCommandFlags(t *testing.T) {
+	flags := make([]Flag, 1, 4)
+	flags[0] = Flag{Long: "all"}
+	CompleteFlags(&Command{Flags: flags}, "--")
+	if spare := flags[:2][1]; spare.Long != "" {
+		t.Errorf("CompleteFlags() wrote %q into the command's flags", spare.Long)
+	}
+}
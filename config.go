@@ -0,0 +1,581 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Settings are the fields of memo.conf. Each one's value comes from, in
+// order: its MEMO_<SETTING> environment variable, the project's
+// .memo/memo.conf, the user's memo.conf, then its default.
+
+const (
+	SETTING_STRING = iota
+	SETTING_PATH   // a string with a leading ~ expanded
+	SETTING_INT    // a positive number
+	SETTING_CHOICE // one of Choices
+	SETTING_LIST   // a comma separated list from Choices
+	SETTING_WIDTHS // a comma separated list of COLUMN=N
+	SETTING_PATHS  // a comma separated list of NAME=PATH
+)
+
+const (
+	SOURCE_DEFAULT = "default"
+	SOURCE_FILE    = "file"
+	SOURCE_LOCAL   = "local file"
+	SOURCE_ENV     = "env"
+)
+
+const (
+	COLOR_AUTO   = "auto"
+	COLOR_ALWAYS = "always"
+	COLOR_NEVER  = "never"
+)
+
+const (
+	FORMAT_TABLE = "table"
+	FORMAT_PLAIN = "plain"
+)
+
+type Setting struct {
+	Key     string // the field in memo.conf
+	Kind    int
+	Choices []string // for SETTING_CHOICE and SETTING_LIST
+	Default string   // written as for `memo config set`, empty for none
+	Global  bool     // only read from the user's config, not a project's
+	Help    string
+}
+
+var SETTINGS = []*Setting{
+	{Key: "SavesDir", Kind: SETTING_PATH, Default: path.Join(ConfigDir(), "memo", "saves"), Global: true, Help: "The directory of the global store of memos."},
+	{Key: "Notebooks", Kind: SETTING_PATHS, Global: true, Help: "The notebooks besides the default one in `SavesDir`, as NAME=PATH. Changed with `" + APP_NAME + " " + CMD_NOTEBOOK + "`."},
+	{Key: "Notebook", Kind: SETTING_STRING, Default: NOTEBOOK_DEFAULT, Help: "The notebook used as the global store without (--notebook)."},
+	{Key: "DefaultStore", Kind: SETTING_CHOICE, Choices: []string{STORE_GLOBAL, STORE_LOCAL}, Default: STORE_GLOBAL, Help: "Where new memos are saved without (--global) or (--local), when in a project with a " + LOCAL_DIR + " directory."},
+	// Commands are global, so a checked out project can't run its own
+	{Key: "Editor", Kind: SETTING_STRING, Global: true, Help: "The editor command, used instead of $VISUAL and $EDITOR."},
+	{Key: "DefaultEditor", Kind: SETTING_STRING, Global: true, Help: "The editor command when neither $VISUAL nor $EDITOR is set, otherwise vi or nano."},
+	{Key: "Shell", Kind: SETTING_STRING, Global: true, Help: "The shell memos are run with, otherwise $SHELL or /bin/sh."},
+	{Key: "Clipboard", Kind: SETTING_STRING, Default: CLIPBOARD_AUTO, Global: true, Help: "How memos are copied: auto, osc52 or a command reading from stdin."},
+	{Key: "Color", Kind: SETTING_CHOICE, Choices: []string{COLOR_AUTO, COLOR_ALWAYS, COLOR_NEVER}, Default: COLOR_AUTO, Help: "Whether content is highlighted. auto only highlights when the output is a terminal, and not when NO_COLOR is set or TERM is dumb."},
+	{Key: "Format", Kind: SETTING_CHOICE, Choices: []string{FORMAT_TABLE, FORMAT_PLAIN}, Default: FORMAT_TABLE, Help: "How memos are printed: a table, or plain tab-separated lines as with (-n/--no-format)."},
+	{Key: "Columns", Kind: SETTING_LIST, Choices: ALL_COLUMNS, Default: strings.Join(DEFAULT_COLUMNS, ","), Help: "The columns printed when (--columns) isn't given."},
+	{Key: "Sort", Kind: SETTING_CHOICE, Choices: ALL_SORTS, Default: SORT_HASH, Help: "The order memos are printed in when (--sort) isn't given."},
+	{Key: "ColumnWidths", Kind: SETTING_WIDTHS, Help: "Maximum widths of the title, content and tags columns, e.g. title=30,content=50."},
+	{Key: "SuggestTimeout", Kind: SETTING_INT, Default: fmt.Sprint(DEFAULT_SUGGEST_TIMEOUT), Help: "Seconds a placeholder suggestions command can run for."},
+	{Key: "SuggestCacheTTL", Kind: SETTING_INT, Default: fmt.Sprint(DEFAULT_SUGGEST_CACHE_TTL), Help: "Seconds placeholder suggestions are cached for."},
+}
+
+// The setting named key, ignoring case, or nil
+func FindSetting(key string) *Setting {
+	for _, setting := range SETTINGS {
+		if strings.EqualFold(setting.Key, key) {
+			return setting
+		}
+	}
+	return nil
+}
+
+// Each setting with its help, for `memo config set`
+func SettingsHelp() string {
+	help := "The settings are"
+	for _, setting := range SETTINGS {
+		help += fmt.Sprintf(" %s: %s", setting.Key, setting.Help)
+		switch setting.Kind {
+		case SETTING_CHOICE:
+			help += fmt.Sprintf(" One of %s.", strings.Join(setting.Choices, ", "))
+		case SETTING_LIST:
+			help += fmt.Sprintf(" From %s.", strings.Join(setting.Choices, ", "))
+		}
+	}
+	return help
+}
+
+func SettingKeys() []string {
+	keys := []string{}
+	for _, setting := range SETTINGS {
+		keys = append(keys, setting.Key)
+	}
+	return keys
+}
+
+// The environment variable overriding the setting, e.g. MEMO_SAVES_DIR
+func (setting *Setting) Env() string {
+	name := ""
+	previous := ' '
+	for _, r := range setting.Key {
+		if unicode.IsUpper(r) && unicode.IsLower(previous) {
+			name += "_"
+		}
+		name += string(unicode.ToUpper(r))
+		previous = r
+	}
+	return "MEMO_" + name
+}
+
+// The value as stored in memo.conf, from how it's written on the command line
+func (setting *Setting) Parse(text string) (any, error) {
+	text = strings.TrimSpace(text)
+	switch setting.Kind {
+	case SETTING_PATH:
+		if text == "" {
+			return nil, errors.New("expected a path")
+		}
+		return ExpandHome(text), nil
+	case SETTING_INT:
+		number, err := strconv.Atoi(text)
+		if err != nil || number < 1 {
+			return nil, fmt.Errorf("expected a positive number, not '%s'", text)
+		}
+		return number, nil
+	case SETTING_CHOICE:
+		choice := strings.ToLower(text)
+		if !slices.Contains(setting.Choices, choice) {
+			return nil, fmt.Errorf("expected one of %s, not '%s'", strings.Join(setting.Choices, ", "), text)
+		}
+		return choice, nil
+	case SETTING_LIST:
+		list := []string{}
+		for _, item := range strings.Split(text, ",") {
+			item = strings.ToLower(strings.TrimSpace(item))
+			if !slices.Contains(setting.Choices, item) {
+				return nil, fmt.Errorf("expected a list from %s, not '%s'", strings.Join(setting.Choices, ", "), item)
+			}
+			list = append(list, item)
+		}
+		return list, nil
+	case SETTING_WIDTHS:
+		widths := make(map[string]int)
+		if text == "" {
+			return widths, nil
+		}
+		for _, item := range strings.Split(text, ",") {
+			column, width, found := strings.Cut(strings.TrimSpace(item), "=")
+			column = strings.ToLower(strings.TrimSpace(column))
+			number, err := strconv.Atoi(strings.TrimSpace(width))
+			if !found || err != nil || number < 1 {
+				return nil, fmt.Errorf("expected COLUMN=N, not '%s'", item)
+			}
+			if !slices.Contains(ALL_COLUMNS, column) {
+				return nil, fmt.Errorf("unknown column '%s', expected one of %s", column, strings.Join(ALL_COLUMNS, ", "))
+			}
+			widths[column] = number
+		}
+		return widths, nil
+	case SETTING_PATHS:
+		paths := make(map[string]string)
+		if text == "" {
+			return paths, nil
+		}
+		for _, item := range strings.Split(text, ",") {
+			name, dir, found := strings.Cut(strings.TrimSpace(item), "=")
+			name, dir = strings.TrimSpace(name), strings.TrimSpace(dir)
+			if !found || dir == "" {
+				return nil, fmt.Errorf("expected NAME=PATH, not '%s'", item)
+			}
+			if err := CheckNotebookName(name); err != nil {
+				return nil, err
+			}
+			paths[name] = ExpandHome(dir)
+		}
+		return paths, nil
+	}
+	return text, nil
+}
+
+// The path with a leading ~ replaced by the home directory
+func ExpandHome(text string) string {
+	if text == "~" || strings.HasPrefix(text, "~/") {
+		home, _ := os.UserHomeDir()
+		return path.Join(home, text[1:])
+	}
+	return text
+}
+
+// Checks a value from memo.conf, returning it as written on the command line
+// even when it's invalid
+func (setting *Setting) Check(raw json.RawMessage) (string, error) {
+	var text string
+	switch setting.Kind {
+	case SETTING_INT:
+		var number int
+		if err := json.Unmarshal(raw, &number); err != nil {
+			return string(raw), fmt.Errorf("expected a number, not %s", raw)
+		}
+		if number != 0 {
+			text = fmt.Sprint(number)
+		}
+	case SETTING_LIST:
+		list := []string{}
+		if err := json.Unmarshal(raw, &list); err != nil {
+			return string(raw), fmt.Errorf("expected a list of strings, not %s", raw)
+		}
+		text = strings.Join(list, ",")
+	case SETTING_WIDTHS:
+		widths := make(map[string]int)
+		if err := json.Unmarshal(raw, &widths); err != nil {
+			return string(raw), fmt.Errorf("expected an object of numbers, not %s", raw)
+		}
+		items := []string{}
+		for column, width := range widths {
+			items = append(items, fmt.Sprintf("%s=%d", column, width))
+		}
+		sort.Strings(items)
+		text = strings.Join(items, ",")
+	case SETTING_PATHS:
+		paths := make(map[string]string)
+		if err := json.Unmarshal(raw, &paths); err != nil {
+			return string(raw), fmt.Errorf("expected an object of strings, not %s", raw)
+		}
+		items := []string{}
+		for name, dir := range paths {
+			items = append(items, fmt.Sprintf("%s=%s", name, dir))
+		}
+		sort.Strings(items)
+		text = strings.Join(items, ",")
+	default:
+		if err := json.Unmarshal(raw, &text); err != nil {
+			return string(raw), fmt.Errorf("expected a string, not %s", raw)
+		}
+	}
+	// Empty values, and 0 for numbers, are how unset ones have been saved
+	if text == "" {
+		return text, nil
+	}
+	_, err := setting.Parse(text)
+	return text, err
+}
+
+// Where the user config is read from: $MEMO_CONF_PATH, otherwise memo.conf
+// in the user config directory
+var config_path string
+
+func ConfigDir() string {
+	user_dir, _ := os.UserHomeDir()
+	user_config_dir, _ := os.UserConfigDir()
+	return strings.Replace(user_config_dir, "~", user_dir, 1)
+}
+
+// A config file as written, so settings can be changed without losing
+// anything else in it
+type ConfigFile struct {
+	Path   string
+	Source string // SOURCE_FILE for the user's, SOURCE_LOCAL for a project's
+	Values map[string]json.RawMessage
+}
+
+// A project's config is optional, so is empty when missing
+func ReadConfigFile(file string, source string) (*ConfigFile, error) {
+	config_file := &ConfigFile{Path: file, Source: source, Values: make(map[string]json.RawMessage)}
+	err := FromJson(&config_file.Values, file)
+	if source == SOURCE_LOCAL && errors.Is(err, os.ErrNotExist) {
+		err = nil
+	}
+	return config_file, err
+}
+
+// The user's config, then the project's if there is one
+func ReadConfigFiles() ([]*ConfigFile, error) {
+	files := []*ConfigFile{}
+	paths := map[string]string{SOURCE_FILE: config_path, SOURCE_LOCAL: LocalConfigPath()}
+	for _, source := range []string{SOURCE_FILE, SOURCE_LOCAL} {
+		if paths[source] == "" {
+			continue
+		}
+		file, err := ReadConfigFile(paths[source], source)
+		if err != nil {
+			return nil, fmt.Errorf("'%s': %v", paths[source], err)
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// The raw value of the setting in file, or nil. Global settings are only
+// read from the user's config.
+func (file *ConfigFile) Lookup(setting *Setting) json.RawMessage {
+	if setting.Global && file.Source != SOURCE_FILE {
+		return nil
+	}
+	for key, raw := range file.Values {
+		if strings.EqualFold(key, setting.Key) {
+			return raw
+		}
+	}
+	return nil
+}
+
+// The effective value of a setting as written on the command line, and
+// where it came from. Later files take precedence and empty values count
+// as unset.
+func SettingValue(setting *Setting, files []*ConfigFile) (string, string, error) {
+	if text := strings.TrimSpace(os.Getenv(setting.Env())); text != "" {
+		_, err := setting.Parse(text)
+		return text, SOURCE_ENV, err
+	}
+	for i := len(files) - 1; i >= 0; i-- {
+		if raw := files[i].Lookup(setting); raw != nil {
+			text, err := setting.Check(raw)
+			if text != "" || err != nil {
+				return text, files[i].Source, err
+			}
+		}
+	}
+	return setting.Default, SOURCE_DEFAULT, nil
+}
+
+// Sets config from the config files, the environment and the defaults.
+// Unknown settings are warned about, as are invalid ones, which are left out
+// so `memo config` can still fix them.
+func ApplySettings(config *Config, files []*ConfigFile) {
+	for _, file := range files {
+		for key := range file.Values {
+			if setting := FindSetting(key); setting == nil {
+				fmt.Fprintf(os.Stderr, "Unknown setting '%s' in '%s', expected one of %s\n", key, file.Path, strings.Join(SettingKeys(), ", "))
+			} else if file.Lookup(setting) == nil {
+				fmt.Fprintf(os.Stderr, "Setting '%s' in '%s' is ignored, it can only be set in '%s'\n", key, file.Path, config_path)
+			}
+		}
+	}
+
+	values := make(map[string]any)
+	for _, setting := range SETTINGS {
+		text, source, err := SettingValue(setting, files)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid %s, using the default: %v\n", SettingSource(setting, source, files), err)
+			text = setting.Default
+		}
+		if text == "" {
+			continue
+		}
+		values[setting.Key], _ = setting.Parse(text)
+	}
+	bytes, _ := json.Marshal(values)
+	if err := FromJsonBytes(config, bytes); err != nil {
+		dataError(fmt.Sprintf("Could not read config '%s': %v", config_path, err), EXIT_STORAGE)
+	}
+}
+
+// Names where a setting's value came from for messages, e.g. MEMO_SORT
+func SettingSource(setting *Setting, source string, files []*ConfigFile) string {
+	if source == SOURCE_ENV {
+		return setting.Env()
+	}
+	for _, file := range files {
+		if file.Source == source {
+			return fmt.Sprintf("'%s' in '%s'", setting.Key, file.Path)
+		}
+	}
+	return setting.Key
+}
+
+/************
+ * Commands *
+ ************/
+
+var CONFIG_LOCAL_FLAG = Flag{Long: "local", Help: "Uses the project's " + LOCAL_DIR + "/memo.conf rather than the user's config."}
+
+func readConfigFilesOrExit() []*ConfigFile {
+	files, err := ReadConfigFiles()
+	if err != nil {
+		dataError(fmt.Sprintf("Could not read config %v", err), EXIT_STORAGE)
+	}
+	return files
+}
+
+// The config file (--local) chooses
+func targetConfigFile(args *Args) *ConfigFile {
+	if args.Has("local") {
+		RequireLocalDir()
+		return readConfigFileOrExit(LocalConfigPath(), SOURCE_LOCAL)
+	}
+	return readConfigFileOrExit(config_path, SOURCE_FILE)
+}
+
+func readConfigFileOrExit(path string, source string) *ConfigFile {
+	file, err := ReadConfigFile(path, source)
+	if err != nil {
+		dataError(fmt.Sprintf("Could not read config '%s': %v", path, err), EXIT_STORAGE)
+	}
+	return file
+}
+
+func findSettingOrExit(key string) *Setting {
+	setting := FindSetting(key)
+	if setting == nil {
+		cliError(fmt.Sprintf("Unknown setting '%s', expected one of %s", key, strings.Join(SettingKeys(), ", ")))
+	}
+	return setting
+}
+
+// Removes the setting from file, whatever case it was written in
+func (file *ConfigFile) Delete(setting *Setting) bool {
+	found := false
+	for key := range file.Values {
+		if strings.EqualFold(key, setting.Key) {
+			delete(file.Values, key)
+			found = true
+		}
+	}
+	return found
+}
+
+func (file *ConfigFile) WriteOrExit() {
+	debugf("Writing '%s'", file.Path)
+	if err := ToJson(file.Values, file.Path); err != nil {
+		dataError(fmt.Sprintf("Could not write config '%s': %v", file.Path, err), EXIT_STORAGE)
+	}
+}
+
+// `memo config ls`
+func ListSettings(args *Args) {
+	files := readConfigFilesOrExit()
+	skip_formatting := args.Has("no-format")
+	rows := [][]string{}
+	for _, setting := range SETTINGS {
+		text, source, err := SettingValue(setting, files)
+		if source == SOURCE_ENV {
+			source += " " + setting.Env()
+		}
+		if err != nil {
+			source += " (invalid)"
+		}
+		rows = append(rows, []string{setting.Key, text, source})
+	}
+
+	widths := []int{0, 0}
+	for _, row := range rows {
+		widths[0] = max(widths[0], StringWidth(row[0]))
+		widths[1] = max(widths[1], StringWidth(row[1]))
+	}
+	for _, row := range rows {
+		if skip_formatting {
+			fmt.Println(strings.Join(row, "\t"))
+		} else {
+			fmt.Printf("%s  %s  %s\n", PadRight(row[0], widths[0]), PadRight(row[1], widths[1]), row[2])
+		}
+	}
+}
+
+// `memo config get <KEY>`
+func GetSetting(args *Args) {
+	setting := findSettingOrExit(args.Arg(0))
+	text, source, err := SettingValue(setting, readConfigFilesOrExit())
+	if err != nil {
+		dataError(fmt.Sprintf("Invalid %s: %v", setting.Key, err), EXIT_USAGE)
+	}
+	if args.Has("source") {
+		if source == SOURCE_ENV {
+			source += " " + setting.Env()
+		}
+		fmt.Printf("%s\t%s\n", text, source)
+	} else {
+		fmt.Println(text)
+	}
+}
+
+// `memo config set <KEY> <VALUE>`
+func SetSetting(args *Args) {
+	setting := findSettingOrExit(args.Arg(0))
+	value, err := setting.Parse(args.Arg(1))
+	if err != nil {
+		cliError(fmt.Sprintf("Invalid %s: %v", setting.Key, err))
+	}
+	if setting.Global && args.Has("local") {
+		cliError(fmt.Sprintf("%s can only be set in the user's config", setting.Key))
+	}
+
+	file := targetConfigFile(args)
+	file.Delete(setting)
+	file.Values[setting.Key], _ = json.Marshal(value)
+	file.WriteOrExit()
+	noteOverrides(setting, file)
+}
+
+// Tells when the setting just written to file is overridden elsewhere
+func noteOverrides(setting *Setting, file *ConfigFile) {
+	if strings.TrimSpace(os.Getenv(setting.Env())) != "" {
+		infof("%s is set, which takes precedence over the config", setting.Env())
+	} else if _, source, _ := SettingValue(setting, readConfigFilesOrExit()); source != file.Source && source != SOURCE_DEFAULT {
+		infof("%s is also set in the project's config, which takes precedence", setting.Key)
+	}
+}
+
+// `memo config unset <KEY>`
+func UnsetSetting(args *Args) {
+	setting := findSettingOrExit(args.Arg(0))
+	file := targetConfigFile(args)
+	if !file.Delete(setting) {
+		infof("%s isn't set in '%s'", setting.Key, file.Path)
+		return
+	}
+	file.WriteOrExit()
+}
+
+// `memo config path`
+func PrintConfigPath(args *Args) {
+	if args.Has("local") {
+		RequireLocalDir()
+		fmt.Println(LocalConfigPath())
+	} else {
+		fmt.Println(config_path)
+	}
+}
+
+// `memo config edit`, reopening the editor until the config is valid
+func EditConfig(ui *Ui, config *Config, args *Args) {
+	file := targetConfigFile(args)
+	bytes, err := os.ReadFile(file.Path)
+	if errors.Is(err, os.ErrNotExist) && file.Source == SOURCE_LOCAL {
+		bytes, err = []byte("{\n}\n"), nil
+	} else if err != nil {
+		dataError(fmt.Sprintf("Could not read config '%s': %v", file.Path, err), EXIT_STORAGE)
+	}
+
+	document := string(bytes)
+	for {
+		edited, err := ui.EditContent(config, document, "json")
+		if err != nil {
+			dataError(fmt.Sprintf("Config not changed: %v", err), EditExitStatus(err))
+		}
+		document = edited
+		if err = CheckConfig(edited, file.Source); err == nil {
+			break
+		}
+		fmt.Fprintf(ui.Out, "Invalid config: %v\n", err)
+	}
+
+	debugf("Writing '%s'", file.Path)
+	if err := os.WriteFile(file.Path, []byte(document), 0644); err != nil {
+		dataError(fmt.Sprintf("Could not write config '%s': %v", file.Path, err), EXIT_STORAGE)
+	}
+}
+
+// An error for invalid JSON or settings in the text of a config file
+func CheckConfig(text string, source string) error {
+	file := &ConfigFile{Source: source, Values: make(map[string]json.RawMessage)}
+	if err := FromJsonBytes(&file.Values, []byte(text)); err != nil {
+		return err
+	}
+	for key, raw := range file.Values {
+		setting := FindSetting(key)
+		if setting == nil {
+			infof("Unknown setting '%s', expected one of %s", key, strings.Join(SettingKeys(), ", "))
+			continue
+		}
+		if file.Lookup(setting) == nil {
+			return fmt.Errorf("%s can only be set in the user's config", key)
+		}
+		if _, err := setting.Check(raw); err != nil {
+			return fmt.Errorf("%s: %v", key, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestConfigFileLookup(t *testing.T) {
+	values := map[string]json.RawMessage{}
+	for _, key := range []string{"SavesDir", "Editor", "DefaultEditor", "Shell", "Clipboard", "Sort"} {
+		values[key] = json.RawMessage(`"x"`)
+	}
+	user := &ConfigFile{Source: SOURCE_FILE, Values: values}
+	project := &ConfigFile{Source: SOURCE_LOCAL, Values: values}
+
+	tests := []struct {
+		key   string
+		local bool // whether a project's config can set it
+	}{
+		{"SavesDir", false},
+		// Settings holding a command
+		{"Editor", false},
+		{"DefaultEditor", false},
+		{"Shell", false},
+		{"Clipboard", false},
+		{"Sort", true},
+	}
+	for _, test := range tests {
+		setting := FindSetting(test.key)
+		if user.Lookup(setting) == nil {
+			t.Errorf("%s isn't read from the user's config", test.key)
+		}
+		if got := project.Lookup(setting) != nil; got != test.local {
+			t.Errorf("%s read from a project's config = %v, want %v", test.key, got, test.local)
+		}
+	}
+}
+
+func TestSettingEnv(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"SavesDir", "MEMO_SAVES_DIR"},
+		{"Sort", "MEMO_SORT"},
+		{"SuggestCacheTTL", "MEMO_SUGGEST_CACHE_TTL"},
+		{"DefaultEditor", "MEMO_DEFAULT_EDITOR"},
+	}
+	for _, test := range tests {
+		if got := FindSetting(test.key).Env(); got != test.want {
+			t.Errorf("%s.Env() = %q, want %q", test.key, got, test.want)
+		}
+	}
+}
+
+func TestSettingParse(t *testing.T) {
+	home, _ := os.UserHomeDir()
+	tests := []struct {
+		key  string
+		text string
+		want any
+		err  string // empty for none
+	}{
+		// SETTING_STRING
+		{"Editor", " code --wait ", "code --wait", ""},
+		// SETTING_PATH
+		{"SavesDir", "/srv/memos", "/srv/memos", ""},
+		{"SavesDir", "~/memos", filepath.Join(home, "memos"), ""},
+		{"SavesDir", " ", nil, "expected a path"},
+		// SETTING_INT
+		{"SuggestTimeout", "10", 10, ""},
+		{"SuggestTimeout", "0", nil, "expected a positive number, not '0'"},
+		{"SuggestTimeout", "soon", nil, "expected a positive number, not 'soon'"},
+		// SETTING_CHOICE
+		{"Color", "Always", COLOR_ALWAYS, ""},
+		{"Color", "sometimes", nil, "expected one of auto, always, never, not 'sometimes'"},
+		// SETTING_LIST
+		{"Columns", "Hash, title", []string{COLUMN_HASH, COLUMN_TITLE}, ""},
+		{"Columns", "hash,size", nil, "expected a list from hash, title, content, tags, type, created, updated, source, not 'size'"},
+		// SETTING_WIDTHS
+		{"ColumnWidths", "Title=30, content=50", map[string]int{"title": 30, "content": 50}, ""},
+		{"ColumnWidths", "", map[string]int{}, ""},
+		{"ColumnWidths", "title=0", nil, "expected COLUMN=N, not 'title=0'"},
+		{"ColumnWidths", "title", nil, "expected COLUMN=N, not 'title'"},
+		{"ColumnWidths", "size=3", nil, "unknown column 'size', expected one of hash, title, content, tags, type, created, updated, source"},
+		// SETTING_PATHS
+		{"Notebooks", "work=/srv/work, home=~/notes", map[string]string{"work": "/srv/work", "home": filepath.Join(home, "notes")}, ""},
+		{"Notebooks", "work", nil, "expected NAME=PATH, not 'work'"},
+		{"Notebooks", "-work=/srv", nil, "notebook names are letters, digits, '-', '_' and '.' after the first, not '-work'"},
+		{"Notebooks", "default=/srv", nil, "'default' is the notebook in `SavesDir`"},
+	}
+	for _, test := range tests {
+		got, err := FindSetting(test.key).Parse(test.text)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%s.Parse(%q) = %v, %v, want the error %q", test.key, test.text, got, err, test.err)
+			}
+		} else if err != nil || !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s.Parse(%q) = %#v, %v, want %#v", test.key, test.text, got, err, test.want)
+		}
+	}
+}
+
+func TestSettingCheck(t *testing.T) {
+	tests := []struct {
+		key  string
+		raw  string
+		want string
+		err  string // empty for none
+	}{
+		{"Sort", `"title"`, SORT_TITLE, ""},
+		{"Sort", `""`, "", ""},
+		{"Sort", `"size"`, "size", "expected one of title, created, updated, tag, hash, not 'size'"},
+		{"Sort", `3`, "3", "expected a string, not 3"},
+		{"SuggestTimeout", `10`, "10", ""},
+		{"SuggestTimeout", `0`, "", ""},
+		{"SuggestTimeout", `-1`, "-1", "expected a positive number, not '-1'"},
+		{"SuggestTimeout", `"10"`, `"10"`, `expected a number, not "10"`},
+		{"Columns", `["hash","title"]`, "hash,title", ""},
+		{"Columns", `"hash"`, `"hash"`, `expected a list of strings, not "hash"`},
+		{"ColumnWidths", `{"title":30,"content":50}`, "content=50,title=30", ""},
+		{"ColumnWidths", `{"title":"wide"}`, `{"title":"wide"}`, `expected an object of numbers, not {"title":"wide"}`},
+		{"Notebooks", `{"work":"/srv/work"}`, "work=/srv/work", ""},
+		{"Notebooks", `["work"]`, `["work"]`, `expected an object of strings, not ["work"]`},
+	}
+	for _, test := range tests {
+		got, err := FindSetting(test.key).Check(json.RawMessage(test.raw))
+		if got != test.want {
+			t.Errorf("%s.Check(%s) = %q, want %q", test.key, test.raw, got, test.want)
+		}
+		if test.err == "" && err != nil || test.err != "" && (err == nil || err.Error() != test.err) {
+			t.Errorf("%s.Check(%s) error = %v, want %q", test.key, test.raw, err, test.err)
+		}
+	}
+}
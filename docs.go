@@ -0,0 +1,333 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const APP_DESCRIPTION = "records memos, short titled notes such as shell commands, to find, show and run later"
+
+type Example struct {
+	Command string
+	Help    string
+}
+
+type Exit struct {
+	Code string // a number, or N for statuses passed through
+	Help string
+}
+
+var DEFAULT_EXITS = []Exit{
+	{Code: fmt.Sprint(EXIT_OK), Help: "Success."},
+	{Code: fmt.Sprint(EXIT_ERROR), Help: "An error without a status of its own."},
+	{Code: fmt.Sprint(EXIT_USAGE), Help: "Invalid arguments or flags."},
+	{Code: fmt.Sprint(EXIT_STORAGE), Help: "The config or the memos couldn't be read or written."},
+}
+
+// For commands taking an IDENTIFIER
+var IDENTIFIER_EXITS = []Exit{
+	{Code: fmt.Sprint(EXIT_NOT_FOUND), Help: "No memo matches the identifier."},
+	{Code: fmt.Sprint(EXIT_AMBIGUOUS), Help: "More than one memo matches the identifier."},
+}
+
+var ABORTED_EXIT = Exit{Code: fmt.Sprint(EXIT_ABORTED), Help: "Cancelled at a prompt or in the editor."}
+
+var NO_INPUT_EXIT = Exit{Code: fmt.Sprint(EXIT_NO_INPUT), Help: "A prompt, the editor or a picker was needed but can't be used, with --non-interactive, without a terminal or at the end of input."}
+
+// For commands that may prompt
+var PROMPT_EXITS = []Exit{NO_INPUT_EXIT, ABORTED_EXIT}
+
+var ENVIRONMENT = []struct {
+	Name string
+	Help string
+}{
+	{Name: "MEMO_CONF_PATH", Help: "The config file, instead of memo.conf in the user configuration directory."},
+	{Name: "VISUAL, EDITOR", Help: "The editor for memo content, unless `Editor` is set in the config."},
+	{Name: "SHELL", Help: "The shell memos are run with, unless `Shell` is set in the config."},
+	{Name: "HISTFILE", Help: "The shell history read by `" + APP_NAME + " " + CMD_CAPTURE + "`."},
+	{Name: "MEMO_SESSION", Help: "Names the session that placeholder suggestions are cached for, instead of the parent process."},
+	{Name: "NO_COLOR", Help: "Turns off syntax highlighting when set, unless `Color` is always."},
+	{Name: "MEMO_NOTEBOOK", Help: "The notebook used as the global store, unless (--notebook) is given. See `" + APP_NAME + " " + CMD_NOTEBOOK + "`."},
+	{Name: "MEMO_<SETTING>", Help: "Overrides a setting from the config, e.g. MEMO_SAVES_DIR or MEMO_SORT. See `" + APP_NAME + " " + CMD_CONFIG + " " + CMD_LIST + "`."},
+}
+
+// A command along with the command line leading to it, e.g. "memo tag"
+type CommandPath struct {
+	Prefix  string
+	Command *Command
+}
+
+func (path CommandPath) Name() string {
+	return path.Prefix + " " + path.Command.Name
+}
+
+// Every runnable command, subcommands included, leaving out hidden ones
+func AllCommands(commands []*Command, prefix string) []CommandPath {
+	paths := []CommandPath{}
+	for _, command := range commands {
+		if command.Hidden {
+			continue
+		}
+		if len(command.Subcommands) > 0 {
+			paths = append(paths, AllCommands(command.Subcommands, prefix+" "+command.Name)...)
+		} else {
+			paths = append(paths, CommandPath{Prefix: prefix, Command: command})
+		}
+	}
+	return paths
+}
+
+// The first sentence of the command's help
+func (command *Command) Summary() string {
+	summary, _, _ := strings.Cut(command.Help, ". ")
+	return strings.TrimSuffix(summary, ".") + "."
+}
+
+func (command *Command) ExitCodes() []Exit {
+	exits := slices.Clone(DEFAULT_EXITS)
+	if slices.ContainsFunc(command.Args, func(arg Arg) bool { return arg.Name == "IDENTIFIER" }) {
+		exits = append(exits, IDENTIFIER_EXITS...)
+	}
+	return SortExits(append(exits, command.Exits...))
+}
+
+// By code, with N, for the statuses passed through, last
+func SortExits(exits []Exit) []Exit {
+	order := func(exit Exit) int {
+		code, err := strconv.Atoi(exit.Code)
+		if err != nil {
+			return math.MaxInt
+		}
+		return code
+	}
+	slices.SortStableFunc(exits, func(a Exit, b Exit) int { return cmp.Compare(order(a), order(b)) })
+	return exits
+}
+
+// Writes text wrapped to width, indented. A width of 0 doesn't wrap.
+func WriteWrapped(out io.Writer, text string, indent int, width int) {
+	if text == "" {
+		return
+	}
+	if width == 0 || width-1-indent < COLUMN_MIN_WIDTH {
+		fmt.Fprintln(out, strings.Repeat(" ", indent)+text)
+		return
+	}
+	for _, chunk := range Chunks(text, width-1-indent) {
+		fmt.Fprintln(out, strings.Repeat(" ", indent)+chunk)
+	}
+}
+
+/********
+ * Help *
+ ********/
+
+// `memo help <COMMAND>`, or the list of commands without one
+func ShowHelp(args *Args) {
+	if len(args.Positional) == 0 {
+		help()
+		return
+	}
+
+	commands := COMMANDS
+	prefix := APP_NAME
+	for i, name := range args.Positional {
+		command := FindCommand(commands, name)
+		if command == nil {
+			cliError(fmt.Sprintf("Unknown command '%s %s'", prefix, name))
+		}
+		if len(command.Subcommands) == 0 || i == len(args.Positional)-1 {
+			PrintCommandHelp(CommandPath{Prefix: prefix, Command: command})
+			return
+		}
+		commands = command.Subcommands
+		prefix += " " + command.Name
+	}
+}
+
+func PrintCommandHelp(path CommandPath) {
+	out := os.Stdout
+	width := GetTermWidth()
+	command := path.Command
+
+	if len(command.Subcommands) > 0 {
+		WriteWrapped(out, fmt.Sprintf("Usage: %s <COMMAND>", path.Name()), 0, width)
+		fmt.Fprintln(out)
+		WriteWrapped(out, command.Help, 0, width)
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Commands:")
+		for _, subcommand := range AllCommands(command.Subcommands, path.Name()) {
+			WriteWrapped(out, subcommand.Command.Usage(subcommand.Prefix), 4, width)
+			WriteWrapped(out, subcommand.Command.Summary(), 8, width)
+		}
+		fmt.Fprintln(out)
+		fmt.Fprintf(out, "Run `%s %s %s <COMMAND>` for more.\n", APP_NAME, CMD_HELP, strings.TrimPrefix(path.Name(), APP_NAME+" "))
+		return
+	}
+
+	WriteWrapped(out, "Usage: "+command.Usage(path.Prefix), 0, width)
+	fmt.Fprintln(out)
+	WriteWrapped(out, command.Help, 0, width)
+
+	if len(command.Flags) > 0 {
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Options:")
+		for _, flag := range command.Flags {
+			usage := FlagUsage(&flag)
+			WriteWrapped(out, usage[1:len(usage)-1], 4, width)
+			WriteWrapped(out, flag.Help, 8, width)
+		}
+	}
+
+	if len(command.Examples) > 0 {
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Examples:")
+		for _, example := range command.Examples {
+			WriteWrapped(out, "# "+example.Help, 4, width)
+			WriteWrapped(out, "$ "+example.Command, 4, width)
+		}
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Exit status:")
+	for _, exit := range command.ExitCodes() {
+		WriteWrapped(out, fmt.Sprintf("%-4s%s", exit.Code, exit.Help), 4, width)
+	}
+}
+
+/************
+ * Man page *
+ ************/
+
+// Escapes text for roff, which treats backslashes and lines starting with
+// a dot or quote specially
+func roffEscape(text string) string {
+	text = strings.ReplaceAll(text, "\\", "\\e")
+	text = strings.ReplaceAll(text, "-", "\\-")
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = "\\&" + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func roffFlag(flag *Flag) string {
+	name := `\fB\-\-` + roffEscape(flag.Long) + `\fR`
+	if flag.Short != "" {
+		name = `\fB\-` + flag.Short + `\fR, ` + name
+	}
+	if flag.Value != "" {
+		name += ` \fI` + roffEscape(flag.Value) + `\fR`
+	}
+	return name
+}
+
+// `memo gen-man`, a man page for section 1
+func GenerateManPage(args *Args) {
+	out := os.Stdout
+	fmt.Fprintf(out, ".TH %s 1 \"\" \"%s %s\" \"User Commands\"\n", strings.ToUpper(APP_NAME), APP_NAME, VERSION)
+	fmt.Fprintln(out, ".SH NAME")
+	fmt.Fprintf(out, "%s \\- %s\n", APP_NAME, roffEscape(APP_DESCRIPTION))
+	fmt.Fprintln(out, ".SH SYNOPSIS")
+	fmt.Fprintf(out, ".B %s\n.I COMMAND\n[\\fIOPTIONS\\fR] [\\fIARGUMENTS\\fR]\n", APP_NAME)
+	fmt.Fprintln(out, ".SH OPTIONS")
+	fmt.Fprintln(out, "These are taken by every command.")
+	for _, flag := range GLOBAL_FLAGS {
+		fmt.Fprintf(out, ".TP\n%s\n%s\n", roffFlag(&flag), roffEscape(flag.Help))
+	}
+	fmt.Fprintln(out, ".SH COMMANDS")
+	for _, path := range AllCommands(COMMANDS, APP_NAME) {
+		command := path.Command
+		fmt.Fprintf(out, ".SS \"%s\"\n", roffEscape(strings.TrimPrefix(path.Name(), APP_NAME+" ")))
+		fmt.Fprintf(out, ".B %s\n.br\n", roffEscape(command.Usage(path.Prefix)))
+		fmt.Fprintln(out, roffEscape(command.Help))
+		for _, flag := range command.Flags {
+			fmt.Fprintf(out, ".TP\n%s\n%s\n", roffFlag(&flag), roffEscape(flag.Help))
+		}
+		for _, example := range command.Examples {
+			fmt.Fprintf(out, ".PP\n%s\n.PP\n.RS\n.nf\n%s\n.fi\n.RE\n", roffEscape(example.Help), roffEscape(example.Command))
+		}
+		if len(command.Exits) > 0 {
+			fmt.Fprintln(out, ".PP\nExit status:")
+			for _, exit := range command.Exits {
+				fmt.Fprintf(out, ".TP\n.B %s\n%s\n", exit.Code, roffEscape(exit.Help))
+			}
+		}
+	}
+	fmt.Fprintln(out, ".SH EXIT STATUS")
+	for _, exit := range SortExits(append(append(slices.Clone(DEFAULT_EXITS), IDENTIFIER_EXITS...), PROMPT_EXITS...)) {
+		fmt.Fprintf(out, ".TP\n.B %s\n%s\n", exit.Code, roffEscape(exit.Help))
+	}
+	fmt.Fprintln(out, ".SH ENVIRONMENT")
+	for _, variable := range ENVIRONMENT {
+		fmt.Fprintf(out, ".TP\n.B %s\n%s\n", roffEscape(variable.Name), roffEscape(variable.Help))
+	}
+	fmt.Fprintln(out, ".SH FILES")
+	fmt.Fprintf(out, ".TP\n.I memo.conf\n%s\n", roffEscape("The JSON config, in the user configuration directory. `SavesDir` is where memos are saved, one JSON file each."))
+	fmt.Fprintf(out, ".TP\n.I .memo/\n%s\n", roffEscape("A project's local store, found by walking up from the working directory. Its memos are in saves/ and its optional memo.conf is merged over the user config."))
+	fmt.Fprintf(out, ".TP\n.I notebooks/\n%s\n", roffEscape("Next to the default saves directory, the directories of notebooks created without --dir."))
+}
+
+/************
+ * Markdown *
+ ************/
+
+// `memo gen-docs --markdown`, a command reference
+func GenerateDocs(args *Args) {
+	if !args.Has("markdown") {
+		cliError("No format given, only (--markdown) is supported")
+	}
+
+	out := os.Stdout
+	fmt.Fprintf(out, "# %s command reference\n\n", APP_NAME)
+	fmt.Fprintf(out, "`%s` %s.\n\n", APP_NAME, APP_DESCRIPTION)
+	paths := AllCommands(COMMANDS, APP_NAME)
+	for _, path := range paths {
+		anchor := strings.ReplaceAll(path.Name(), " ", "-")
+		fmt.Fprintf(out, "- [`%s`](#%s)\n", path.Name(), anchor)
+	}
+
+	for _, path := range paths {
+		command := path.Command
+		fmt.Fprintf(out, "\n## %s\n\n", path.Name())
+		fmt.Fprintf(out, "```\n%s\n```\n\n", command.Usage(path.Prefix))
+		fmt.Fprintln(out, command.Help)
+		if len(command.Flags) > 0 {
+			fmt.Fprintln(out, "\n| Option | Description |\n|--------|-------------|")
+			for _, flag := range command.Flags {
+				usage := FlagUsage(&flag)
+				fmt.Fprintf(out, "| `%s` | %s |\n", usage[1:len(usage)-1], strings.ReplaceAll(flag.Help, "|", "\\|"))
+			}
+		}
+		if len(command.Examples) > 0 {
+			fmt.Fprintln(out, "\n```shell")
+			for _, example := range command.Examples {
+				fmt.Fprintf(out, "# %s\n$ %s\n", example.Help, example.Command)
+			}
+			fmt.Fprintln(out, "```")
+		}
+		fmt.Fprintln(out, "\nExit status:")
+		for _, exit := range command.ExitCodes() {
+			fmt.Fprintf(out, "- `%s` %s\n", exit.Code, exit.Help)
+		}
+	}
+
+	fmt.Fprint(out, "\n## Options for every command\n\n")
+	for _, flag := range GLOBAL_FLAGS {
+		usage := FlagUsage(&flag)
+		fmt.Fprintf(out, "- `%s` %s\n", usage[1:len(usage)-1], flag.Help)
+	}
+
+	fmt.Fprint(out, "\n## Environment\n\n")
+	for _, variable := range ENVIRONMENT {
+		fmt.Fprintf(out, "- `%s` %s\n", variable.Name, variable.Help)
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// A memo is edited as a document with its fields in a header above the
+// content:
+//
+//	---
+//	title: Kill process using port
+//	tags: network, unix
+//	type: sh
+//	no-run: false
+//	suggest.port: lsof -i -P | awk '{print $9}'
+//	---
+//	kill $(lsof -t -i:{port:3001})
+const (
+	FRONT_MATTER_FENCE   = "---"
+	FRONT_MATTER_TITLE   = "title"
+	FRONT_MATTER_TAGS    = "tags"
+	FRONT_MATTER_TYPE    = "type"
+	FRONT_MATTER_NO_RUN  = "no-run"
+	FRONT_MATTER_SUGGEST = "suggest."
+	FRONT_MATTER_ERROR   = "# error: "
+)
+
+var placeholderNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+func FormatFrontMatter(memo *Memo) string {
+	var document strings.Builder
+	field := func(name string, value string) {
+		document.WriteString(strings.TrimRight(name+": "+value, " ") + "\n")
+	}
+	document.WriteString(FRONT_MATTER_FENCE + "\n")
+	field(FRONT_MATTER_TITLE, memo.Title)
+	field(FRONT_MATTER_TAGS, strings.Join(memo.Tags, ", "))
+	field(FRONT_MATTER_TYPE, memo.Type)
+	field(FRONT_MATTER_NO_RUN, fmt.Sprint(memo.NoRun))
+	for _, name := range slices.Sorted(maps.Keys(memo.Suggestions)) {
+		field(FRONT_MATTER_SUGGEST+name, memo.Suggestions[name])
+	}
+	document.WriteString(FRONT_MATTER_FENCE + "\n")
+	document.WriteString(memo.Content)
+	return document.String()
+}
+
+// Reads an edited document back into a copy of memo. The title must not be
+// saved in the same file as any of the other memos'.
+func ParseFrontMatter(document string, memo *Memo, memos map[HASH]*Memo) (*Memo, error) {
+	header, content, ok := strings.Cut(document, "\n")
+	if !ok || strings.TrimSpace(header) != FRONT_MATTER_FENCE {
+		return nil, fmt.Errorf("the document must start with a '%s' line", FRONT_MATTER_FENCE)
+	}
+
+	updated := *memo
+	updated.Tags = []string{}
+	updated.Suggestions = nil
+	found_end := false
+	for !found_end {
+		var line string
+		line, content, ok = strings.Cut(content, "\n")
+		line = strings.TrimSpace(line)
+		if line == FRONT_MATTER_FENCE {
+			found_end = true
+			continue
+		}
+		if !ok {
+			return nil, fmt.Errorf("no '%s' line ending the header", FRONT_MATTER_FENCE)
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, has_value := strings.Cut(line, ":")
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		if !has_value {
+			return nil, fmt.Errorf("'%s' should be 'name: value'", line)
+		}
+		switch {
+		case key == FRONT_MATTER_TITLE:
+			updated.Title = value
+		case key == FRONT_MATTER_TAGS:
+			for _, tag := range strings.Split(value, ",") {
+				tag = strings.TrimSpace(tag)
+				if tag != "" && !slices.Contains(updated.Tags, tag) {
+					updated.Tags = append(updated.Tags, tag)
+				}
+			}
+		case key == FRONT_MATTER_TYPE:
+			if value == "" {
+				updated.Type = ""
+			} else if language := GetLanguage(value); language != nil {
+				updated.Type = language.Name
+			} else {
+				return nil, fmt.Errorf("unknown type '%s', expected one of %s", value, strings.Join(LanguageNames(), ", "))
+			}
+		case key == FRONT_MATTER_NO_RUN:
+			switch strings.ToLower(value) {
+			case "true", "yes":
+				updated.NoRun = true
+			case "false", "no", "":
+				updated.NoRun = false
+			default:
+				return nil, fmt.Errorf("%s should be true or false, not '%s'", FRONT_MATTER_NO_RUN, value)
+			}
+		case strings.HasPrefix(key, FRONT_MATTER_SUGGEST):
+			name := strings.TrimSpace(line[len(FRONT_MATTER_SUGGEST):strings.Index(line, ":")])
+			if !placeholderNamePattern.MatchString(name) {
+				return nil, fmt.Errorf("'%s' is not a placeholder name", name)
+			}
+			if value != "" {
+				if updated.Suggestions == nil {
+					updated.Suggestions = make(map[string]string)
+				}
+				updated.Suggestions[name] = value
+			}
+		default:
+			return nil, fmt.Errorf("unknown field '%s'", key)
+		}
+	}
+
+	if updated.Title == "" {
+		return nil, fmt.Errorf("the title can't be empty")
+	}
+	filename := ToFilename(updated.Title, "")
+	for _, other := range memos {
+		// Titles only need to be unique within a store
+		if other == memo || other.Dir != memo.Dir {
+			continue
+		}
+		if ToFilename(other.Title, "") == filename {
+			return nil, fmt.Errorf("memo '%s' already exists", other.Title)
+		}
+	}
+
+	// Editors add a newline to the end of the file
+	if !strings.HasSuffix(memo.Content, "\n") {
+		content = strings.TrimSuffix(content, "\n")
+	}
+	updated.Content = content
+	return &updated, nil
+}
+
+// Adds an error to the top of the header, replacing any from before, so it
+// can be seen when the document is opened again
+func AddFrontMatterError(document string, err error) string {
+	lines := strings.Split(document, "\n")
+	kept := []string{}
+	in_header := true
+	for i, line := range lines {
+		if i > 0 && strings.TrimSpace(line) == FRONT_MATTER_FENCE {
+			in_header = false
+		}
+		if i > 0 && in_header && strings.HasPrefix(line, FRONT_MATTER_ERROR) {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	if len(kept) > 0 && strings.TrimSpace(kept[0]) == FRONT_MATTER_FENCE {
+		return strings.Join(slices.Insert(kept, 1, FRONT_MATTER_ERROR+err.Error()), "\n")
+	}
+	return strings.Join(slices.Insert(kept, 0, FRONT_MATTER_FENCE, FRONT_MATTER_ERROR+err.Error(), FRONT_MATTER_FENCE), "\n")
+}
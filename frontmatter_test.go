@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseFrontMatter(t *testing.T) {
+	memo := &Memo{Title: "Kill process", Content: "kill {pid}", Tags: []string{"unix"}}
+	other := &Memo{Title: "Other", Content: "echo"}
+	memos := map[HASH]*Memo{"1": memo, "2": other}
+
+	tests := []struct {
+		name     string
+		document string
+		want     *Memo // nil when an error is expected
+	}{
+		{
+			"unchanged",
+			FormatFrontMatter(memo) + "\n",
+			&Memo{Title: "Kill process", Content: "kill {pid}", Tags: []string{"unix"}},
+		},
+		{
+			"every field",
+			"---\ntitle:  Kill port \ntags: network, unix, network\ntype: sh\nno-run: yes\n# a comment\nsuggest.pid: pgrep node\n---\nkill {pid}\n",
+			&Memo{Title: "Kill port", Content: "kill {pid}", Tags: []string{"network", "unix"}, Type: "sh", NoRun: true, Suggestions: map[string]string{"pid": "pgrep node"}},
+		},
+		{
+			"case only rename",
+			"---\ntitle: kill PROCESS\n---\nkill {pid}",
+			&Memo{Title: "kill PROCESS", Content: "kill {pid}", Tags: []string{}},
+		},
+		{"no header", "kill {pid}", nil},
+		{"unterminated header", "---\ntitle: Kill process\nkill {pid}", nil},
+		{"not a field", "---\ntitle\n---\n", nil},
+		{"unknown field", "---\ntitle: Kill process\ncolour: red\n---\n", nil},
+		{"unknown type", "---\ntitle: Kill process\ntype: cobol\n---\n", nil},
+		{"invalid no-run", "---\ntitle: Kill process\nno-run: maybe\n---\n", nil},
+		{"invalid placeholder name", "---\ntitle: Kill process\nsuggest.1st: ls\n---\n", nil},
+		{"empty title", "---\ntitle:\n---\n", nil},
+		{"title taken", "---\ntitle: Other\n---\n", nil},
+		{"title taken in another case", "---\ntitle: OTHER\n---\n", nil},
+	}
+	for _, test := range tests {
+		got, err := ParseFrontMatter(test.document, memo, memos)
+		if test.want == nil {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %+v", test.name, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+			continue
+		}
+		if got.Title != test.want.Title || got.Content != test.want.Content || got.Type != test.want.Type || got.NoRun != test.want.NoRun ||
+			!slices.Equal(got.Tags, test.want.Tags) || !maps.Equal(got.Suggestions, test.want.Suggestions) {
+			t.Errorf("%s: got %+v, want %+v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestAddFrontMatterError(t *testing.T) {
+	tests := []struct {
+		document string
+		want     string
+	}{
+		{"---\ntitle: A\n---\nx", "---\n# error: bad\ntitle: A\n---\nx"},
+		// An earlier error is replaced
+		{"---\n# error: old\ntitle: A\n---\nx", "---\n# error: bad\ntitle: A\n---\nx"},
+		{"x", "---\n# error: bad\n---\nx"},
+	}
+	for _, test := range tests {
+		if got := AddFrontMatterError(test.document, errors.New("bad")); got != test.want {
+			t.Errorf("AddFrontMatterError(%q) = %q, want %q", test.document, got, test.want)
+		}
+	}
+}
+
+func TestFormatFrontMatter(t *testing.T) {
+	memo := &Memo{Title: "A", Content: "x", Tags: []string{"b", "c"}, Suggestions: map[string]string{"z": "1", "y": "2"}}
+	want := "---\ntitle: A\ntags: b, c\ntype:\nno-run: false\nsuggest.y: 2\nsuggest.z: 1\n---\nx"
+	if got := FormatFrontMatter(memo); got != want {
+		t.Errorf("FormatFrontMatter() = %q, want %q", got, want)
+	}
+	if !strings.HasPrefix(FormatFrontMatter(&Memo{}), FRONT_MATTER_FENCE+"\n") {
+		t.Errorf("FormatFrontMatter() should start with %q", FRONT_MATTER_FENCE)
+	}
+}
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/term"
+)
+
+type Language struct {
+	Name            string
+	Aliases         []string
+	Extension       string
+	Runnable        bool
+	Interpreter     []string // nil runs through the configured shell
+	LineComment     string
+	Keywords        []string
+	CaseInsensitive bool // keywords match in any case
+	Flags           bool // highlight -f/--flag words
+	Variables       bool // highlight $VAR and ${VAR}
+}
+
+const (
+	TYPE_SHELL      = "sh"
+	TYPE_FISH       = "fish"
+	TYPE_PYTHON     = "python"
+	TYPE_JAVASCRIPT = "javascript"
+	TYPE_RUBY       = "ruby"
+	TYPE_SQL        = "sql"
+	TYPE_VIM        = "vim"
+	TYPE_MARKDOWN   = "markdown"
+	TYPE_YAML       = "yaml"
+	TYPE_JSON       = "json"
+	TYPE_TEXT       = "text"
+)
+
+var LANGUAGES = []*Language{
+	{
+		Name:        TYPE_SHELL,
+		Aliases:     []string{"shell", "bash", "zsh", "ksh", "dash", "console"},
+		Extension:   "sh",
+		Runnable:    true,
+		LineComment: "#",
+		Keywords: []string{
+			"if", "then", "else", "elif", "fi", "for", "while", "until", "do", "done",
+			"case", "esac", "in", "function", "return", "local", "export", "exit", "sudo",
+		},
+		Flags:     true,
+		Variables: true,
+	},
+	{
+		Name:        TYPE_FISH,
+		Extension:   "fish",
+		Runnable:    true,
+		Interpreter: []string{"fish", "-c"},
+		LineComment: "#",
+		Keywords: []string{
+			"if", "else", "end", "for", "while", "in", "function", "return", "set",
+			"switch", "case", "and", "or", "not", "begin", "exit", "sudo",
+		},
+		Flags:     true,
+		Variables: true,
+	},
+	{
+		Name:        TYPE_PYTHON,
+		Aliases:     []string{"py", "python2", "python3"},
+		Extension:   "py",
+		Runnable:    true,
+		Interpreter: []string{"python3", "-c"},
+		LineComment: "#",
+		Keywords: []string{
+			"def", "class", "if", "elif", "else", "for", "while", "in", "import", "from",
+			"as", "return", "with", "try", "except", "finally", "raise", "lambda", "None",
+			"True", "False", "and", "or", "not", "pass", "break", "continue", "yield",
+			"async", "await",
+		},
+	},
+	{
+		Name:        TYPE_JAVASCRIPT,
+		Aliases:     []string{"js", "node"},
+		Extension:   "js",
+		Runnable:    true,
+		Interpreter: []string{"node", "-e"},
+		LineComment: "//",
+		Keywords: []string{
+			"function", "const", "let", "var", "if", "else", "for", "while", "return",
+			"new", "class", "import", "from", "export", "async", "await", "try", "catch",
+			"throw", "null", "undefined", "true", "false", "this",
+		},
+	},
+	{
+		Name:        TYPE_RUBY,
+		Aliases:     []string{"rb"},
+		Extension:   "rb",
+		Runnable:    true,
+		Interpreter: []string{"ruby", "-e"},
+		LineComment: "#",
+		Keywords: []string{
+			"def", "end", "if", "elsif", "else", "unless", "while", "do", "class",
+			"module", "return", "nil", "true", "false", "require", "puts",
+		},
+	},
+	{
+		Name:        TYPE_SQL,
+		Aliases:     []string{"mysql", "psql", "postgres", "postgresql", "sqlite", "sqlite3"},
+		Extension:   "sql",
+		LineComment: "--",
+		Keywords: []string{
+			"select", "from", "where", "insert", "into", "values", "update", "set",
+			"delete", "create", "table", "drop", "alter", "join", "left", "right",
+			"inner", "outer", "on", "group", "by", "order", "having", "limit", "and",
+			"or", "not", "null", "as", "distinct", "union", "all", "index", "primary",
+			"key", "begin", "commit", "rollback", "explain", "analyze",
+		},
+		CaseInsensitive: true,
+	},
+	{
+		Name:        TYPE_VIM,
+		Aliases:     []string{"vimscript", "viml", "ex", "nvim"},
+		Extension:   "vim",
+		LineComment: "\"",
+		Keywords: []string{
+			"set", "let", "if", "endif", "for", "endfor", "function", "endfunction",
+			"call", "map", "noremap", "nnoremap", "inoremap", "vnoremap", "autocmd",
+			"syntax", "command",
+		},
+	},
+	{
+		Name:      TYPE_MARKDOWN,
+		Aliases:   []string{"md"},
+		Extension: "md",
+	},
+	{
+		Name:        TYPE_YAML,
+		Aliases:     []string{"yml"},
+		Extension:   "yaml",
+		LineComment: "#",
+		Keywords:    []string{"true", "false", "null"},
+	},
+	{
+		Name:      TYPE_JSON,
+		Extension: "json",
+		Keywords:  []string{"true", "false", "null"},
+	},
+	{
+		Name:      TYPE_TEXT,
+		Aliases:   []string{"txt", "plain", "prose"},
+		Extension: "txt",
+	},
+}
+
+// Finds a language by name or alias, nil if unknown
+func GetLanguage(name string) *Language {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return nil
+	}
+	for _, language := range LANGUAGES {
+		if language.Name == name {
+			return language
+		}
+		for _, alias := range language.Aliases {
+			if alias == name {
+				return language
+			}
+		}
+	}
+	return nil
+}
+
+func LanguageNames() []string {
+	names := []string{}
+	for _, language := range LANGUAGES {
+		names = append(names, language.Name)
+	}
+	return names
+}
+
+// Validates a --type argument, returning the canonical name
+func ParseType(name string) string {
+	language := GetLanguage(name)
+	if language == nil {
+		cliError(fmt.Sprintf("Unknown type '%s', expected one of %s", name, strings.Join(LanguageNames(), ", ")))
+	}
+	return language.Name
+}
+
+// The temp file extension for editing content of this type
+func TypeExtension(memo_type string) string {
+	if language := GetLanguage(memo_type); language != nil {
+		return language.Extension
+	}
+	return ""
+}
+
+// Memos without a type are assumed to be shell commands, as most are
+func IsRunnable(memo *Memo) bool {
+	if memo.NoRun {
+		return false
+	}
+	if memo.Type == "" {
+		return true
+	}
+	language := GetLanguage(memo.Type)
+	return language != nil && language.Runnable
+}
+
+// The language must be on the fence line itself, not the first line of a
+// fence without one
+var fencePattern = regexp.MustCompile("(?m)^[ \\t]*```[ \\t]*([A-Za-z0-9_+-]+)")
+
+// Works out the type of content from a shebang line or the language of a
+// fenced code block. Returns "" if neither says.
+func DetectType(content string) string {
+	first_line, _, _ := strings.Cut(strings.TrimLeft(content, "\n"), "\n")
+	if strings.HasPrefix(first_line, "#!") {
+		fields := strings.Fields(strings.TrimPrefix(first_line, "#!"))
+		interpreter := ""
+		for i, field := range fields {
+			if i == 0 {
+				interpreter = filepath.Base(field)
+			} else if interpreter == "env" && !strings.HasPrefix(field, "-") {
+				interpreter = filepath.Base(field)
+			}
+		}
+		// e.g. python3.12
+		interpreter = strings.TrimRightFunc(interpreter, func(r rune) bool {
+			return unicode.IsDigit(r) || r == '.'
+		})
+		if language := GetLanguage(interpreter); language != nil {
+			return language.Name
+		}
+	}
+
+	if match := fencePattern.FindStringSubmatch(content); match != nil {
+		if language := GetLanguage(match[1]); language != nil {
+			return language.Name
+		}
+	}
+	return ""
+}
+
+/****************
+ * Highlighting *
+ ****************/
+
+const (
+	HIGHLIGHT_KEYWORD     = "\x1b[1;34m"
+	HIGHLIGHT_STRING      = "\x1b[32m"
+	HIGHLIGHT_COMMENT     = "\x1b[90m"
+	HIGHLIGHT_FLAG        = "\x1b[33m"
+	HIGHLIGHT_VARIABLE    = "\x1b[35m"
+	HIGHLIGHT_NUMBER      = "\x1b[36m"
+	HIGHLIGHT_PLACEHOLDER = "\x1b[4;35m"
+)
+
+// Whether to highlight, from the `Color` setting. With auto, only when
+// stdout is a terminal, so escape codes stay out of files and pipes, and
+// honouring https://no-color.org
+func UseColor() bool {
+	switch config.Color {
+	case COLOR_ALWAYS:
+		return true
+	case COLOR_NEVER:
+		return false
+	}
+	return term.IsTerminal(int(os.Stdout.Fd())) && os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb"
+}
+
+func isWordRune(r byte) bool {
+	return r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+}
+
+// Adds ANSI colours to a single line of content of the given type. Lines are
+// highlighted on their own so that wrapping can't split a colour across cells.
+func Highlight(memo_type string, line string) string {
+	language := GetLanguage(memo_type)
+	if language == nil || (len(language.Keywords) == 0 && language.LineComment == "") {
+		return line
+	}
+
+	placeholders := make(map[int]int)
+	for _, match := range findPlaceholderMatches(line) {
+		if !match.Escaped {
+			placeholders[match.Start] = match.End
+		}
+	}
+
+	var highlighted strings.Builder
+	color := func(code string, text string) {
+		highlighted.WriteString(code + text + ESC_RESET)
+	}
+	at_word_start := func(i int) bool {
+		return i == 0 || line[i-1] == ' ' || line[i-1] == '\t'
+	}
+
+	for i := 0; i < len(line); {
+		rest := line[i:]
+		c := line[i]
+		switch {
+		case language.LineComment != "" && strings.HasPrefix(rest, language.LineComment) &&
+			(language.Name == TYPE_VIM && strings.TrimSpace(line[0:i]) == "" ||
+				language.Name != TYPE_VIM && at_word_start(i)):
+			color(HIGHLIGHT_COMMENT, rest)
+			i = len(line)
+		case placeholders[i] > 0:
+			color(HIGHLIGHT_PLACEHOLDER, line[i:placeholders[i]])
+			i = placeholders[i]
+		case c == '"' || c == '\'' || (c == '`' && language.Name == TYPE_JAVASCRIPT):
+			end := i + 1
+			for end < len(line) && line[end] != c {
+				if line[end] == '\\' && c != '\'' {
+					end++
+				}
+				end++
+			}
+			end = min(end+1, len(line))
+			color(HIGHLIGHT_STRING, line[i:end])
+			i = end
+		case language.Variables && c == '$' && i+1 < len(line) && line[i+1] == '{':
+			end := strings.IndexByte(rest, '}')
+			if end == -1 {
+				end = len(rest) - 1
+			}
+			color(HIGHLIGHT_VARIABLE, rest[0:end+1])
+			i += end + 1
+		case language.Variables && c == '$' && i+1 < len(line) && (isWordRune(line[i+1]) || strings.IndexByte("@#?$!*", line[i+1]) >= 0):
+			end := i + 2
+			for end < len(line) && isWordRune(line[end]) && !(line[i+1] >= '0' && line[i+1] <= '9') {
+				end++
+			}
+			color(HIGHLIGHT_VARIABLE, line[i:end])
+			i = end
+		case language.Flags && c == '-' && at_word_start(i) && i+1 < len(line) && line[i+1] != ' ':
+			end := strings.IndexAny(rest, " \t=")
+			if end == -1 {
+				end = len(rest)
+			}
+			color(HIGHLIGHT_FLAG, rest[0:end])
+			i += end
+		case isWordRune(c):
+			end := i
+			for end < len(line) && isWordRune(line[end]) {
+				end++
+			}
+			word := line[i:end]
+			is_keyword := false
+			for _, keyword := range language.Keywords {
+				if word == keyword || (language.CaseInsensitive && strings.EqualFold(word, keyword)) {
+					is_keyword = true
+					break
+				}
+			}
+			if is_keyword {
+				color(HIGHLIGHT_KEYWORD, word)
+			} else if strings.Trim(word, "0123456789") == "" {
+				color(HIGHLIGHT_NUMBER, word)
+			} else {
+				highlighted.WriteString(word)
+			}
+			i = end
+		default:
+			highlighted.WriteByte(c)
+			i++
+		}
+	}
+	return highlighted.String()
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestDetectType(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"#!/bin/bash\necho hi", TYPE_SHELL},
+		{"\n\n#!/bin/zsh\necho hi", TYPE_SHELL},
+		{"#!/usr/bin/env python3\nprint(1)", TYPE_PYTHON},
+		{"#!/usr/bin/python3.12", TYPE_PYTHON},
+		// env's own options are skipped
+		{"#!/usr/bin/env -S node --no-warnings\n", TYPE_JAVASCRIPT},
+		{"#! /usr/bin/env ruby", TYPE_RUBY},
+		{"Notes\n```sql\nselect 1;\n```", TYPE_SQL},
+		{"  ```js\nlet a\n```", TYPE_JAVASCRIPT},
+		// An unknown interpreter falls back to the fence
+		{"#!/usr/bin/perl\n```ruby\nputs 1\n```", TYPE_RUBY},
+		{"#!/usr/bin/perl", ""},
+		{"```unknown\n```", ""},
+		{"```\nplain\n```", ""},
+		{"echo '#!/bin/bash'", ""},
+		{"", ""},
+	}
+	for _, test := range tests {
+		if got := DetectType(test.content); got != test.want {
+			t.Errorf("DetectType(%q) = %q, want %q", test.content, got, test.want)
+		}
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	color := func(code string) func(string) string {
+		return func(text string) string { return code + text + ESC_RESET }
+	}
+	keyword, str, comment := color(HIGHLIGHT_KEYWORD), color(HIGHLIGHT_STRING), color(HIGHLIGHT_COMMENT)
+	flag, variable, number := color(HIGHLIGHT_FLAG), color(HIGHLIGHT_VARIABLE), color(HIGHLIGHT_NUMBER)
+	placeholder := color(HIGHLIGHT_PLACEHOLDER)
+
+	tests := []struct {
+		memo_type string
+		line      string
+		want      string
+	}{
+		{TYPE_SHELL, "ls -la /tmp", "ls " + flag("-la") + " /tmp"},
+		{TYPE_SHELL, "git commit --message=hi", "git commit " + flag("--message") + "=hi"},
+		{TYPE_SHELL, "a-b - c", "a-b - c"},
+		// Backslashes only escape in double quotes
+		{TYPE_SHELL, `echo "a \" b" 'c\' d`, "echo " + str(`"a \" b"`) + " " + str(`'c\'`) + " d"},
+		{TYPE_SHELL, `echo "open`, "echo " + str(`"open`)},
+		{TYPE_SHELL, "if true; then exit 1; fi # done", keyword("if") + " true; " + keyword("then") + " " + keyword("exit") + " " + number("1") + "; " + keyword("fi") + " " + comment("# done")},
+		// Only a # starting a word is a comment
+		{TYPE_SHELL, "echo a#b", "echo a#b"},
+		{TYPE_SHELL, "kill {pid:1} $PID ${HOME} $1x $?", "kill " + placeholder("{pid:1}") + " " + variable("$PID") + " " + variable("${HOME}") + " " + variable("$1") + "x " + variable("$?")},
+		{TYPE_SHELL, `echo \{name} "{name}"`, `echo \{name} ` + str(`"{name}"`)},
+		{TYPE_SHELL, "# {name}", comment("# {name}")},
+		{TYPE_PYTHON, "def f(): return None  # note", keyword("def") + " f(): " + keyword("return") + " " + keyword("None") + "  " + comment("# note")},
+		{TYPE_PYTHON, "x = -1 $y", "x = -" + number("1") + " $y"},
+		{TYPE_JAVASCRIPT, "const s = `x`; // c", keyword("const") + " s = " + str("`x`") + "; " + comment("// c")},
+		{TYPE_SQL, "SELECT 1 -- all", keyword("SELECT") + " " + number("1") + " " + comment("-- all")},
+		{TYPE_VIM, `" set number`, comment(`" set number`)},
+		{TYPE_MARKDOWN, "# Title -x", "# Title -x"},
+		{"", "ls -la", "ls -la"},
+	}
+	for _, test := range tests {
+		if got := Highlight(test.memo_type, test.line); got != test.want {
+			t.Errorf("Highlight(%q, %q) =\n%q, want\n%q", test.memo_type, test.line, got, test.want)
+		}
+	}
+}
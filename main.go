@@ -3,128 +3,163 @@ package main
 import (
 	"errors"
 	"fmt"
-	"log"
 	"os"
 	"path"
 	"strings"
 )
 
 const (
-	APP_NAME          = "memo"
-	CMD_ADD           = "add"
-	CMD_EDIT          = "edit"
-	CMD_TAG           = "tag"
-	CMD_TAGS          = "tags"
-	CMD_LIST          = "ls"
-	CMD_REMOVE        = "rm"
-	CMD_SEARCH        = "search"
-	CMD_SHOW          = "show"
-	CMD_VERSION       = "version"
-	CMD_VERSION_LONG  = "--version"
-	CMD_VERSION_SHORT = "-v"
-	HELP              = "--help"
-	HELP_SHORT        = "-h"
-	VERSION           = "1.1.0"
+	APP_NAME             = "memo"
+	CMD_ADD              = "add"
+	CMD_CAPTURE          = "capture"
+	CMD_COMPLETE         = "__complete"
+	CMD_COMPLETION       = "completion"
+	CMD_CONFIG           = "config"
+	CMD_CONFIG_GET       = "get"
+	CMD_CONFIG_PATH      = "path"
+	CMD_CONFIG_SET       = "set"
+	CMD_CONFIG_UNSET     = "unset"
+	CMD_COPY             = "copy"
+	CMD_EDIT             = "edit"
+	CMD_TAG              = "tag"
+	CMD_TAGS             = "tags"
+	CMD_LIST             = "ls"
+	CMD_MOVE             = "mv"
+	CMD_NOTEBOOK         = "notebook"
+	CMD_NOTEBOOK_CREATE  = "create"
+	CMD_NOTEBOOK_DEFAULT = "default"
+	CMD_PICK             = "pick"
+	CMD_REMOVE           = "rm"
+	CMD_RUN              = "run"
+	CMD_SEARCH           = "search"
+	CMD_SHELL_INIT       = "shell-init"
+	CMD_SHOW             = "show"
+	CMD_UI               = "ui"
+	CMD_VERSION          = "version"
+	CMD_VERSION_LONG     = "--version"
+	CMD_VERSION_SHORT    = "-v"
+	CMD_HELP             = "help"
+	CMD_GEN_MAN          = "gen-man"
+	CMD_GEN_DOCS         = "gen-docs"
+	HELP                 = "--help"
+	HELP_SHORT           = "-h"
+	VERSION              = "1.1.0"
 )
 
-type HelpCommand struct {
-	Text    string
-	SubText string
+// Exit statuses, documented in the help of each command
+const (
+	EXIT_OK        = 0
+	EXIT_ERROR     = 1 // any error without a status of its own
+	EXIT_USAGE     = 2 // the command line was invalid
+	EXIT_NOT_FOUND = 3 // no memo matched the identifier
+	EXIT_AMBIGUOUS = 4 // more than one memo matched the identifier
+	EXIT_STORAGE   = 5 // the config or memos couldn't be read or written
+	EXIT_NO_INPUT  = 6 // a prompt couldn't be asked or answered
+	EXIT_ABORTED   = 130
+)
+
+// How much is written to stderr besides errors, set by --quiet and --verbose
+const (
+	VERBOSITY_QUIET = iota
+	VERBOSITY_NORMAL
+	VERBOSITY_VERBOSE
+)
+
+var verbosity = VERBOSITY_NORMAL
+
+// Set by --non-interactive. Prompts are also left out when stdin isn't a
+// terminal, unless there is one to ask on instead.
+var non_interactive = false
+
+var GLOBAL_FLAGS = []Flag{
+	{Long: "quiet", Help: "Only prints errors besides the output asked for."},
+	{Long: "verbose", Help: "Also prints details such as the config used, the files written and the commands run."},
+	{Long: "notebook", Value: "NOTEBOOK", Help: "Uses NOTEBOOK as the global store, instead of $MEMO_NOTEBOOK or the `Notebook` setting."},
+	{Long: "non-interactive", Help: "Never prompts or opens the editor or a picker, for scripts and CI. Answers are taken from flags such as (-y/--yes) and (--var), otherwise the command exits with status 6. Implied when there is no terminal."},
 }
 
+// The list of commands, with `memo help <COMMAND>` giving the details
 func help() {
-	message := []HelpCommand{
-		{
-			Text:    fmt.Sprintf("%s <COMMAND>", APP_NAME),
-			SubText: "",
-		},
-		{
-			Text:    fmt.Sprintf("%s %s <TITLE> (<CONTENTS>) (-t/--tags <TAGS>)", APP_NAME, CMD_ADD),
-			SubText: "Creates a new memo. If no CONTENTS is given, the system text editor will be opened for input. TAGS is a comma separated list.",
-		},
-		{
-			Text:    fmt.Sprintf("%s %s (-a/--accept) <IDENTIFIER> (<CONTENTS>)", APP_NAME, CMD_EDIT),
-			SubText: "Edits a memo. IDENTIFIER is either the memo title or the memo hash. If no CONTENTS is given, the system text editor will be opened for input. If the (-a/--accept) flag is provided, changes are auto-accepted. Otherwise, a diff will be presented for confirmation.",
-		},
-		{
-			Text:    fmt.Sprintf("%s %s (-n/--no-format) (...-t/--tag <TAG>)", APP_NAME, CMD_LIST),
-			SubText: "Prints memos. The (-n/--no-format) flag prints each memo as a single-line with its values tab-separated. Multiple (-t/--tag) options can be used to limit the results by memos with ANY of the listed tags",
-		},
-		{
-			Text:    fmt.Sprintf("%s %s <IDENTIFIER>", APP_NAME, CMD_REMOVE),
-			SubText: "Deletes a memo. IDENTIFIER is either the memo title or the memo hash.",
-		},
-		{
-			Text:    fmt.Sprintf("%s %s  (-t/--title OR -c/--content) (-n/--no-format) <SEARCH_TERM>", APP_NAME, CMD_SEARCH),
-			SubText: "Searches memos. The (-t/--title) limits the search to memo titles. The (-c/--content) limits the search to memo contents. You can only limit the search with one flag at a time. The (-n/--no-format) flag prints each memo as a single-line with its values tab-separated.",
-		},
-		{
-			Text:    fmt.Sprintf("%s %s (-n/--no-format) <IDENTIFIER>", APP_NAME, CMD_SHOW),
-			SubText: "Prints a memo. IDENTIFIER is either the memo title or the memo hash. The (-n/--no-format) flag prints each memo as a single-line with its values tab-separated.",
-		},
-		{
-			Text:    fmt.Sprintf("%s %s %s <IDENTIFIER> <TAG>", APP_NAME, CMD_TAG, CMD_ADD),
-			SubText: "Adds a tag to a memo. IDENTIFIER is either the memo title or the memo hash.",
-		},
-		{
-			Text:    fmt.Sprintf("%s %s %s", APP_NAME, CMD_TAG, CMD_LIST),
-			SubText: "Lists all existing tags",
-		},
-		{
-			Text:    fmt.Sprintf("%s %s", APP_NAME, CMD_TAGS),
-			SubText: fmt.Sprintf("Alias for `%s %s %s`", APP_NAME, CMD_TAG, CMD_LIST),
-		},
-		{
-			Text:    fmt.Sprintf("%s %s %s <IDENTIFIER> <TAG>", APP_NAME, CMD_TAG, CMD_REMOVE),
-			SubText: "Removes a tag to a memo. IDENTIFIER is either the memo title or the memo hash.",
-		},
-		{
-			Text:    fmt.Sprintf("%s (%s/%s/%s)", APP_NAME, CMD_VERSION, CMD_VERSION_LONG, CMD_VERSION_SHORT),
-			SubText: "Prints this current version.",
-		},
-		{
-			Text:    fmt.Sprintf("%s (%s/%s)", APP_NAME, HELP, HELP_SHORT),
-			SubText: "Prints this message.",
-		},
+	width := GetTermWidth()
+	WriteWrapped(os.Stdout, fmt.Sprintf("Usage: %s <COMMAND>", APP_NAME), 0, width)
+	fmt.Println()
+	WriteWrapped(os.Stdout, fmt.Sprintf("%s %s.", APP_NAME, APP_DESCRIPTION), 0, width)
+	fmt.Println()
+	fmt.Println("Commands:")
+	for _, path := range AllCommands(COMMANDS, APP_NAME) {
+		WriteWrapped(os.Stdout, path.Command.Usage(path.Prefix), 4, width)
+		WriteWrapped(os.Stdout, path.Command.Summary(), 8, width)
 	}
+	fmt.Println()
+	fmt.Println("Options for every command:")
+	for _, flag := range GLOBAL_FLAGS {
+		usage := FlagUsage(&flag)
+		WriteWrapped(os.Stdout, usage[1:len(usage)-1], 4, width)
+		WriteWrapped(os.Stdout, flag.Help, 8, width)
+	}
+	fmt.Println()
+	fmt.Printf("Run `%s %s <COMMAND>` or `%s <COMMAND> %s` for more.\n", APP_NAME, CMD_HELP, APP_NAME, HELP)
+}
 
-	width := GetTermWidth()
-	if width == 0 {
-		for _, line := range message {
-			fmt.Println(line.Text)
-			fmt.Println("    " + line.SubText)
-		}
-	} else {
-		for _, line := range message {
-			textChunks := Chunks(line.Text, width-1)
-			subTextChunks := Chunks(line.SubText, width-5)
-			for _, t := range textChunks {
-				fmt.Println(t)
-			}
-			for _, s := range subTextChunks {
-				fmt.Println("    " + s)
-			}
-		}
+// Sets the verbosity from --quiet or --verbose, --non-interactive and
+// the notebook used
+func ApplyGlobalFlags(args *Args) {
+	non_interactive = non_interactive || args.Has("non-interactive")
+	if args.Has("notebook") {
+		config.Notebook = strings.TrimSpace(args.Value("notebook"))
+	}
+	if args.Has("quiet") && args.Has("verbose") {
+		cliError("Only one of (--quiet) and (--verbose) can be given")
+	} else if args.Has("quiet") {
+		verbosity = VERBOSITY_QUIET
+	} else if args.Has("verbose") {
+		verbosity = VERBOSITY_VERBOSE
 	}
 }
 
+// A status message on stderr, left out with --quiet
+func infof(format string, a ...any) {
+	if verbosity >= VERBOSITY_NORMAL {
+		fmt.Fprintln(os.Stderr, fmt.Sprintf(format, a...))
+	}
+}
+
+// A detail on stderr, only written with --verbose
+func debugf(format string, a ...any) {
+	if verbosity >= VERBOSITY_VERBOSE {
+		fmt.Fprintln(os.Stderr, fmt.Sprintf(format, a...))
+	}
+}
+
+// Set by Dispatch so errors can show the usage of the command being run
+var current_command *Command
+var current_prefix string
+
+// A mistake in the command line, exiting with EXIT_USAGE by default
 func cliError(msg string, optional_error_status ...int) {
-	error_status := 1
+	error_status := EXIT_USAGE
 	if len(optional_error_status) > 0 && optional_error_status[0] != 0 {
 		error_status = optional_error_status[0]
 	}
-	fmt.Println(msg)
-	fmt.Println("")
-	help()
+	fmt.Fprintln(os.Stderr, msg)
+	fmt.Fprintln(os.Stderr, "")
+	if current_command != nil {
+		name := strings.TrimPrefix(current_prefix+" "+current_command.Name, APP_NAME+" ")
+		fmt.Fprintln(os.Stderr, "Usage: "+current_command.Usage(current_prefix))
+		fmt.Fprintf(os.Stderr, "Run `%s %s %s` for more.\n", APP_NAME, CMD_HELP, name)
+	} else {
+		fmt.Fprintf(os.Stderr, "Run `%s %s` for the list of commands.\n", APP_NAME, CMD_HELP)
+	}
 	os.Exit(error_status)
 }
 
+// Any other error, exiting with EXIT_ERROR by default
 func dataError(msg string, optional_error_status ...int) {
-	error_status := 1
+	error_status := EXIT_ERROR
 	if len(optional_error_status) > 0 && optional_error_status[0] != 0 {
 		error_status = optional_error_status[0]
 	}
-	fmt.Println(msg)
+	fmt.Fprintln(os.Stderr, msg)
 	os.Exit(error_status)
 }
 
@@ -132,18 +167,38 @@ func PrintVersion() {
 	fmt.Println(VERSION)
 }
 
+// The settings in memo.conf, described by SETTINGS
 type Config struct {
-	SavesDir string
+	SavesDir     string
+	ColumnWidths map[string]int `json:",omitempty"`
+	Shell        string         `json:",omitempty"`
+	Clipboard    string         `json:",omitempty"`
+	// Editor overrides $VISUAL and $EDITOR, DefaultEditor is used when
+	// neither is set
+	Editor        string `json:",omitempty"`
+	DefaultEditor string `json:",omitempty"`
+	// Limits for placeholder suggestion commands, in seconds
+	SuggestTimeout  int `json:",omitempty"`
+	SuggestCacheTTL int `json:",omitempty"`
+	// Defaults for printing memos
+	Color   string   `json:",omitempty"`
+	Format  string   `json:",omitempty"`
+	Columns []string `json:",omitempty"`
+	Sort    string   `json:",omitempty"`
+	// Where new memos go in a project, STORE_GLOBAL or STORE_LOCAL
+	DefaultStore string `json:",omitempty"`
+	// Saves directories by notebook name, and the one used as the global
+	// store, NOTEBOOK_DEFAULT for SavesDir
+	Notebooks map[string]string `json:",omitempty"`
+	Notebook  string            `json:",omitempty"`
 }
 
 var config *Config
 var ui *Ui
 
 func LoadConfig() {
-	config_path := strings.TrimSpace(os.Getenv("MEMO_CONF_PATH"))
-	user_dir, _ := os.UserHomeDir()
-	user_config_dir, _ := os.UserConfigDir()
-	config_dir := strings.Replace(user_config_dir, "~", user_dir, 1)
+	config_path = strings.TrimSpace(os.Getenv("MEMO_CONF_PATH"))
+	config_dir := ConfigDir()
 	if config_path == "" {
 		config_path = path.Join(config_dir, "memo.conf")
 	}
@@ -151,86 +206,42 @@ func LoadConfig() {
 	_, err := os.Stat(config_path)
 	if errors.Is(err, os.ErrNotExist) {
 		default_config := &Config{
-			SavesDir: path.Join(config_dir, "memo", "saves"),
+			SavesDir: FindSetting("SavesDir").Default,
 		}
 		if err := ToJson(default_config, config_path); err != nil {
-			log.Fatal(err)
+			dataError(fmt.Sprintf("Could not create config '%s': %v", config_path, err), EXIT_STORAGE)
 		}
 	} else if err != nil {
-		cliError(
-			"Unknown config access error\n" +
-				fmt.Sprintf("\tLoaded Config Path: '%s'\n", config_path) +
-				fmt.Sprintf("\tSystem Config Dir: '%s'\n", config_dir) +
-				fmt.Sprintf("\tMEMO_CONF_PATH: '%s'\n", os.Getenv("MEMO_CONF_PATH")) +
-				"Consider adjusting/unsetting MEMO_CONF_PATH\n\n" +
-				fmt.Sprintf("%v\n", config_path),
+		dataError(
+			"Unknown config access error\n"+
+				fmt.Sprintf("\tLoaded Config Path: '%s'\n", config_path)+
+				fmt.Sprintf("\tSystem Config Dir: '%s'\n", config_dir)+
+				fmt.Sprintf("\tMEMO_CONF_PATH: '%s'\n", os.Getenv("MEMO_CONF_PATH"))+
+				"Consider adjusting/unsetting MEMO_CONF_PATH\n\n"+
+				fmt.Sprintf("%v\n", err),
+			EXIT_STORAGE,
 		)
 	}
 
-	if err = FromJson(config, config_path); err != nil {
-		log.Fatal(err)
+	local_dir = FindLocalDir(WorkingDir())
+	files, err := ReadConfigFiles()
+	if err != nil {
+		dataError(fmt.Sprintf("Could not read config %v", err), EXIT_STORAGE)
 	}
+	ApplySettings(config, files)
 }
 
-func init() {
+// Done in main rather than init, so that tests don't write a config or pick
+// up a project's .memo directory
+func main() {
 	config = &Config{
 		SavesDir: "",
 	}
 	LoadConfig()
 
-	if err := os.MkdirAll(config.SavesDir, os.ModePerm); err != nil {
-		log.Fatal(err)
-	}
+	CreateStores(config)
 
 	ui = CreateUi()
-}
 
-func main() {
-	if len(os.Args) < 2 {
-		cliError("No arguments given")
-	}
-	command := strings.TrimSpace(os.Args[1])
-	switch command {
-	case HELP:
-		help()
-	case HELP_SHORT:
-		help()
-	case CMD_ADD:
-		AddMemo(ui, config)
-	case CMD_EDIT:
-		EditMemo(ui, config)
-	case CMD_TAG:
-		if len(os.Args) < 3 {
-			cliError("No arguments given")
-		}
-		tagCommand := strings.TrimSpace(os.Args[2])
-		switch tagCommand {
-		case CMD_ADD:
-			AddTag(config)
-		case CMD_LIST:
-			ShowTags(config)
-		case CMD_REMOVE:
-			RemoveTag(config)
-		default:
-			cliError(fmt.Sprintf("Unknown argument '%s'", tagCommand))
-		}
-	case CMD_TAGS:
-		ShowTags(config)
-	case CMD_SEARCH:
-		SearchMemos(ui, config)
-	case CMD_LIST:
-		ShowMemos(ui, config)
-	case CMD_REMOVE:
-		RemoveMemo(ui, config)
-	case CMD_SHOW:
-		ShowMemo(ui, config)
-	case CMD_VERSION:
-		PrintVersion()
-	case CMD_VERSION_LONG:
-		PrintVersion()
-	case CMD_VERSION_SHORT:
-		PrintVersion()
-	default:
-		cliError(fmt.Sprintf("Unknown argument '%s'", command))
-	}
+	Dispatch(COMMANDS, APP_NAME, os.Args[1:])
 }
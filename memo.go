@@ -3,15 +3,25 @@ package main
 import (
 	"crypto/sha1"
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 type Memo struct {
 	Title   string
 	Content string
 	Tags    []string
+	Type    string `json:",omitempty"` // language of the content, see LANGUAGES
+	Created time.Time
+	Updated time.Time
+	NoRun   bool `json:",omitempty"`
+	// Values last used to fill in the content's placeholders
+	LastValues map[string]string `json:",omitempty"`
+	// Shell commands listing candidate values for placeholders, by name
+	Suggestions map[string]string `json:",omitempty"`
+	// The saves directory the memo is read from and written to
+	Dir string `json:"-"`
 }
 
 const (
@@ -28,63 +38,116 @@ func CreateMemo(title string, content string) *Memo {
 	}
 }
 
-func (memo *Memo) Delete(saves_dir string) {
+// The storage methods return their errors rather than exit, as the TUI has
+// the terminal to restore first. Commands use SaveOrExit.
+func (memo *Memo) Delete() error {
 	filename := ToFilename(memo.Title, "")
 	fullpath := filepath.Join(
-		saves_dir,
+		memo.Dir,
 		filename,
 	)
 
-	os.Remove(fullpath)
+	if err := os.Remove(fullpath); err != nil {
+		return err
+	}
+	debugf("Deleted '%s'", fullpath)
+	return nil
+}
+
+func (memo *Memo) Save() (HASH, error) {
+	memo.Touch()
+	return memo.Write()
+}
+
+// Save for commands, exiting with EXIT_STORAGE if it fails
+func (memo *Memo) SaveOrExit() HASH {
+	hash, err := memo.Save()
+	if err != nil {
+		dataError(fmt.Sprintf("Could not save memo '%s': %v", memo.Title, err), EXIT_STORAGE)
+	}
+	return hash
+}
+
+// Marks the memo as updated now, and created if it is new
+func (memo *Memo) Touch() {
+	now := time.Now()
+	if memo.Created.IsZero() {
+		memo.Created = now
+	}
+	memo.Updated = now
 }
 
-func (memo *Memo) Save(saves_dir string) string {
+// Writes the memo without marking it as updated, for bookkeeping changes
+func (memo *Memo) Write() (HASH, error) {
 	filename := ToFilename(memo.Title, "")
 	fullpath := filepath.Join(
-		saves_dir,
+		memo.Dir,
 		filename,
 	)
 
-	ToJson(memo, fullpath)
-	hash := sha1.Sum([]byte(filename))
-	return fmt.Sprintf("%x", hash)
+	if err := ToJson(memo, fullpath); err != nil {
+		return "", err
+	}
+	debugf("Wrote '%s'", fullpath)
+	return memo.Hash(), nil
+}
+
+// The memo's key, from the file it is saved in
+func (memo *Memo) Hash() HASH {
+	return MemoHash(memo.Dir, ToFilename(memo.Title, ""))
 }
 
-func LoadMemo(filename string, memo *Memo, saves_dir string) {
+// The key of the memo saved as filename in saves_dir. Local memos have
+// their own, so one with the same title as a global memo doesn't replace it.
+func MemoHash(saves_dir string, filename string) HASH {
+	if StoreName(saves_dir) == STORE_LOCAL {
+		filename = STORE_LOCAL + "/" + filename
+	}
+	return fmt.Sprintf("%x", sha1.Sum([]byte(filename)))
+}
+
+func LoadMemo(filename string, memo *Memo, saves_dir string) error {
 	filePath := filepath.Join(
 		saves_dir,
 		filename,
 	)
 	err := FromJson(memo, filePath)
 	if err != nil {
-		fmt.Printf("Err: %v", err)
+		return err
 	}
-}
+	memo.Dir = saves_dir
 
-func LoadMemoByHash(saves_dir, hash string) *Memo {
-	memos := LoadMemos(saves_dir)
-	for full_hash, memo := range memos {
-		if full_hash[0:8] == hash {
-			return memo
+	// Memos saved before timestamps were recorded fall back to the file's
+	if memo.Created.IsZero() || memo.Updated.IsZero() {
+		if info, err := os.Stat(filePath); err == nil {
+			if memo.Created.IsZero() {
+				memo.Created = info.ModTime()
+			}
+			if memo.Updated.IsZero() {
+				memo.Updated = info.ModTime()
+			}
 		}
 	}
 	return nil
 }
 
 func LoadMemos(saves_dir string) map[HASH]*Memo {
+	debugf("Reading memos from '%s'", saves_dir)
 	files, err := os.ReadDir(saves_dir)
 	if err != nil {
-		log.Fatal(err)
+		dataError(fmt.Sprintf("Could not read memos from '%s': %v", saves_dir, err), EXIT_STORAGE)
 	}
 
 	memos := make(map[HASH]*Memo)
 	for _, fileEntry := range files {
 		if !fileEntry.IsDir() {
 			var memo *Memo = CreateMemo("", "")
-			LoadMemo(fileEntry.Name(), memo, saves_dir)
-			hash := sha1.Sum([]byte(fileEntry.Name()))
-			hash_str := fmt.Sprintf("%x", hash)
-			memos[hash_str] = memo
+			// One broken file shouldn't hide every other memo
+			if err := LoadMemo(fileEntry.Name(), memo, saves_dir); err != nil {
+				fmt.Fprintf(os.Stderr, "Skipped unreadable memo '%s': %v\n", fileEntry.Name(), err)
+				continue
+			}
+			memos[MemoHash(saves_dir, fileEntry.Name())] = memo
 		}
 	}
 
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMemoStorageErrors(t *testing.T) {
+	memo := CreateMemo("Missing", "content")
+	memo.Dir = filepath.Join(t.TempDir(), "missing")
+	if hash, err := memo.Save(); err == nil {
+		t.Errorf("Save() into a missing directory = %q, expected an error", hash)
+	}
+	if err := memo.Delete(); err == nil {
+		t.Errorf("Delete() of a missing memo should fail")
+	}
+
+	memo.Dir = t.TempDir()
+	if hash, err := memo.Save(); err != nil || hash != memo.Hash() {
+		t.Errorf("Save() = %q, %v, want %q", hash, err, memo.Hash())
+	}
+	if err := memo.Delete(); err != nil {
+		t.Errorf("Delete() = %v", err)
+	}
+}
+
+func TestLoadStoresSameTitle(t *testing.T) {
+	defer func(dir string) { local_dir = dir }(local_dir)
+	local_dir = t.TempDir()
+	config := &Config{SavesDir: t.TempDir(), Notebook: NOTEBOOK_DEFAULT}
+	for _, dir := range []string{config.SavesDir, LocalSavesDir()} {
+		memo := CreateMemo("Deploy", "content")
+		memo.Dir = dir
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := memo.Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	memos := LoadAllMemos(config)
+	if len(memos) != 2 {
+		t.Fatalf("LoadAllMemos() = %d memos, want the local and the global one", len(memos))
+	}
+	var ambiguous *AmbiguousError
+	if _, err := FindMemo(memos, "Deploy"); !errors.As(err, &ambiguous) {
+		t.Fatalf("FindMemo(\"Deploy\") = %v, want ambiguous", err)
+	}
+	if store := StoreName(memos[ambiguous.Hashes[0]].Dir); store != STORE_GLOBAL {
+		t.Errorf("the first match is %s, want the global memo", store)
+	}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Notebooks are separate collections of memos: the default one in
+// `SavesDir` and the saves directories named in the `Notebooks` setting.
+// The one chosen by (--notebook), $MEMO_NOTEBOOK or the `Notebook` setting
+// is used as the global store.
+
+const NOTEBOOK_DEFAULT = "default"
+
+// Names are used on the command line and as directory names, so are kept
+// to letters, digits and a little punctuation
+func CheckNotebookName(name string) error {
+	if name == "" {
+		return errors.New("expected a notebook name")
+	}
+	if name == NOTEBOOK_DEFAULT {
+		return fmt.Errorf("'%s' is the notebook in `SavesDir`", name)
+	}
+	for i, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && (i == 0 || !strings.ContainsRune("-_.", r)) {
+			return fmt.Errorf("notebook names are letters, digits, '-', '_' and '.' after the first, not '%s'", name)
+		}
+	}
+	return nil
+}
+
+// Every notebook's name, the default first
+func NotebookNames(config *Config) []string {
+	names := []string{}
+	for name := range config.Notebooks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append([]string{NOTEBOOK_DEFAULT}, names...)
+}
+
+func NotebookDir(config *Config, name string) (string, bool) {
+	if name == NOTEBOOK_DEFAULT {
+		return config.SavesDir, true
+	}
+	dir, found := config.Notebooks[name]
+	return dir, found
+}
+
+func unknownNotebook(config *Config, name string) {
+	dataError(fmt.Sprintf("Unknown notebook '%s', expected one of %s. Create it with `%s %s %s %s`.", name, strings.Join(NotebookNames(config), ", "), APP_NAME, CMD_NOTEBOOK, CMD_NOTEBOOK_CREATE, name), EXIT_USAGE)
+}
+
+// The saves directory of the notebook in use, created if it's missing
+func GlobalSavesDir(config *Config) string {
+	dir, found := NotebookDir(config, config.Notebook)
+	if !found {
+		unknownNotebook(config, config.Notebook)
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		dataError(fmt.Sprintf("Could not create the saves directory '%s': %v", dir, err), EXIT_STORAGE)
+	}
+	return dir
+}
+
+func countMemos(dir string) int {
+	entries, _ := os.ReadDir(dir)
+	count := 0
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			count++
+		}
+	}
+	return count
+}
+
+// The notebooks as written in the user's config
+func readNotebooks(file *ConfigFile) map[string]string {
+	notebooks := make(map[string]string)
+	if raw := file.Lookup(FindSetting("Notebooks")); raw != nil {
+		if err := json.Unmarshal(raw, &notebooks); err != nil {
+			dataError(fmt.Sprintf("Invalid Notebooks in '%s': %v", file.Path, err), EXIT_STORAGE)
+		}
+	}
+	return notebooks
+}
+
+func writeNotebooks(file *ConfigFile, notebooks map[string]string) {
+	setting := FindSetting("Notebooks")
+	file.Delete(setting)
+	if len(notebooks) > 0 {
+		file.Values[setting.Key], _ = json.Marshal(notebooks)
+	}
+	file.WriteOrExit()
+	noteOverrides(setting, file)
+}
+
+/************
+ * Commands *
+ ************/
+
+// `memo notebook ls`, marking the one in use
+func ListNotebooks(config *Config, args *Args) {
+	names := NotebookNames(config)
+	widths := []int{0, 0}
+	counts := make(map[string]string)
+	for _, name := range names {
+		dir, _ := NotebookDir(config, name)
+		counts[name] = fmt.Sprint(countMemos(dir))
+		widths[0] = max(widths[0], StringWidth(name))
+		widths[1] = max(widths[1], len(counts[name]))
+	}
+	for _, name := range names {
+		dir, _ := NotebookDir(config, name)
+		if args.Has("no-format") {
+			fmt.Printf("%s\t%s\t%s\n", name, counts[name], dir)
+			continue
+		}
+		marker := " "
+		if name == config.Notebook {
+			marker = "*"
+		}
+		fmt.Printf("%s %s  %*s  %s\n", marker, PadRight(name, widths[0]), widths[1], counts[name], dir)
+	}
+}
+
+// `memo notebook create <NAME>`
+func CreateNotebook(config *Config, args *Args) {
+	name := args.Arg(0)
+	if err := CheckNotebookName(name); err != nil {
+		cliError(fmt.Sprintf("Invalid notebook name: %v", err))
+	}
+	if _, found := NotebookDir(config, name); found {
+		dataError(fmt.Sprintf("Notebook '%s' already exists", name))
+	}
+
+	dir := NotebookOwnDir(name)
+	if args.Has("dir") {
+		dir = ExpandHome(strings.TrimSpace(args.Value("dir")))
+	}
+	// Relative to where it's created, not wherever memo is run later
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		dataError(fmt.Sprintf("Invalid directory '%s': %v", dir, err))
+	}
+	for _, other := range NotebookNames(config) {
+		if other_dir, _ := NotebookDir(config, other); filepath.Clean(other_dir) == dir {
+			dataError(fmt.Sprintf("'%s' is already the directory of notebook '%s'", dir, other))
+		}
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		dataError(fmt.Sprintf("Could not create the saves directory '%s': %v", dir, err), EXIT_STORAGE)
+	}
+
+	file := readConfigFileOrExit(config_path, SOURCE_FILE)
+	notebooks := readNotebooks(file)
+	notebooks[name] = dir
+	writeNotebooks(file, notebooks)
+	infof("Created notebook '%s' in '%s'", name, dir)
+}
+
+// Where a notebook created without (--dir) keeps its memos
+func NotebookOwnDir(name string) string {
+	return filepath.Join(ConfigDir(), APP_NAME, "notebooks", name)
+}
+
+// `memo notebook rm <NOTEBOOK>`, deleting its memos unless (--keep-memos)
+// is given. A directory given with (--dir) may hold more than memo put in
+// it, so the memos are only deleted from the notebook's own directory.
+func RemoveNotebook(ui *Ui, config *Config, args *Args) {
+	name := args.Arg(0)
+	if name == NOTEBOOK_DEFAULT {
+		dataError("The default notebook, `SavesDir`, can't be removed")
+	}
+	file := readConfigFileOrExit(config_path, SOURCE_FILE)
+	notebooks := readNotebooks(file)
+	dir, found := notebooks[name]
+	if !found {
+		dataError(fmt.Sprintf("No notebook '%s' in '%s'", name, file.Path))
+	}
+	if text, _, _ := SettingValue(FindSetting("Notebook"), readConfigFilesOrExit()); text == name {
+		dataError(fmt.Sprintf("Notebook '%s' is the default. Choose another with `%s %s %s` first.", name, APP_NAME, CMD_NOTEBOOK, CMD_NOTEBOOK_DEFAULT))
+	}
+
+	if _, err := os.Stat(dir); err == nil && !args.Has("keep-memos") {
+		if filepath.Clean(dir) != NotebookOwnDir(name) {
+			dataError(fmt.Sprintf("Notebook '%s' uses '%s', which memo didn't create, so its memos aren't deleted. Use (--keep-memos) to only remove the notebook.", name, dir))
+		}
+		memos := LoadMemos(dir)
+		if len(memos) > 0 && !args.Has("yes") {
+			question := fmt.Sprintf("Delete notebook '%s' and its %d memos?", name, len(memos))
+			ui.RequirePrompt(question, "Use (-y/--yes) to delete them without asking, or (--keep-memos) to leave them.")
+			if ui.GetResponse(question+" (y/n) ", "Try again: ", []string{"y", "n"}) == "n" {
+				dataError("Nothing changed", EXIT_ABORTED)
+			}
+		}
+		batch := CreateBatch()
+		for _, memo := range memos {
+			batch.Delete(memo)
+		}
+		if err := batch.Commit(); err != nil {
+			dataError(fmt.Sprintf("Nothing removed: %v", err), EXIT_STORAGE)
+		}
+		// Only the memos are deleted, anything else in it stays
+		if err := os.Remove(dir); err != nil {
+			infof("Left '%s' as it isn't empty", dir)
+		}
+	}
+
+	delete(notebooks, name)
+	writeNotebooks(file, notebooks)
+	if args.Has("keep-memos") {
+		infof("Its memos are left in '%s'", dir)
+	}
+}
+
+// `memo notebook default (<NOTEBOOK>)`
+func DefaultNotebook(config *Config, args *Args) {
+	setting := FindSetting("Notebook")
+	name := args.Arg(0)
+	if name == "" {
+		text, _, _ := SettingValue(setting, readConfigFilesOrExit())
+		fmt.Println(text)
+		return
+	}
+	if _, found := NotebookDir(config, name); !found {
+		unknownNotebook(config, name)
+	}
+
+	file := readConfigFileOrExit(config_path, SOURCE_FILE)
+	file.Delete(setting)
+	if name != NOTEBOOK_DEFAULT {
+		file.Values[setting.Key], _ = json.Marshal(name)
+	}
+	file.WriteOrExit()
+	noteOverrides(setting, file)
+}
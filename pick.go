@@ -0,0 +1,275 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const PICKER_MAX_HEIGHT = 10
+
+var ErrPickCancelled = errors.New("cancelled")
+
+type Picker struct {
+	terminal *Terminal
+	prompt   string
+	items    []string
+	query    string
+	matches  []int // indexes into items, best match first
+	cursor   int
+	offset   int
+	height   int // number of rows drawn below the prompt
+}
+
+func PickMemo(ui *Ui, config *Config, args *Args) {
+	query := args.Value("query")
+	print_id := args.Has("print-id")
+	copy_content := args.Has("copy")
+	search_tags := []string{}
+	for _, tag := range args.All("tag") {
+		search_tags = append(search_tags, strings.TrimSpace(tag))
+	}
+	vars := ParseVars(args.All("var"))
+
+	memos := LoadAllMemos(config)
+	hashes := []HASH{}
+	for hash, memo := range memos {
+		if len(search_tags) == 0 || AnyIntersection(search_tags, memo.Tags) {
+			hashes = append(hashes, hash)
+		}
+	}
+	sort.Slice(hashes, func(i, j int) bool {
+		return strings.ToLower(memos[hashes[i]].Title) < strings.ToLower(memos[hashes[j]].Title)
+	})
+	if len(hashes) == 0 {
+		dataError("No memos to pick from")
+	}
+
+	items := []string{}
+	for _, hash := range hashes {
+		items = append(items, MemoPickerLabel(hash, memos[hash]))
+	}
+
+	if non_interactive {
+		dataError("Cannot pick a memo when not interactive. Use `"+APP_NAME+" "+CMD_SHOW+"` with an identifier instead.", EXIT_NO_INPUT)
+	}
+	index, err := Pick("> ", items, query)
+	if errors.Is(err, ErrPickCancelled) {
+		os.Exit(EXIT_ABORTED)
+	} else if err != nil {
+		dataError(fmt.Sprintf("Cannot open picker: %v", err), EXIT_NO_INPUT)
+	}
+
+	hash := hashes[index]
+	content := memos[hash].Content
+	if !print_id || copy_content {
+		content = ui.OnTty().FillMemo(memos[hash], vars, config)
+	}
+	if copy_content {
+		if err := CopyToClipboard(config, content); err != nil {
+			dataError(fmt.Sprintf("Could not copy to the clipboard: %v", err))
+		}
+	}
+	if print_id {
+		fmt.Println(hash[0:8])
+	} else {
+		fmt.Println(strings.TrimRight(content, "\n"))
+	}
+}
+
+func MemoPickerLabel(hash HASH, memo *Memo) string {
+	label := fmt.Sprintf("%s  %s", hash[0:8], memo.Title)
+	if local_dir != "" {
+		label += " (" + StoreName(memo.Dir) + ")"
+	}
+	if len(memo.Tags) > 0 {
+		label += " [" + strings.Join(memo.Tags, ", ") + "]"
+	}
+	if first_line := FirstLine(memo.Content); first_line != "" {
+		label += "  → " + first_line
+	}
+	return label
+}
+
+// Pick lets the user choose one of items on the tty, drawn inline below the
+// cursor rather than taking over the screen. Returns the index of the choice.
+func Pick(prompt string, items []string, query string) (int, error) {
+	in, out, err := OpenTty()
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+	if out != in {
+		defer out.Close()
+	}
+
+	terminal, err := OpenTerminal(in, out)
+	if err != nil {
+		return 0, err
+	}
+	defer terminal.Close()
+
+	_, term_height := terminal.Size()
+	picker := &Picker{
+		terminal: terminal,
+		prompt:   prompt,
+		items:    items,
+		query:    query,
+		height:   max(1, min(PICKER_MAX_HEIGHT, len(items), term_height-1)),
+	}
+	picker.Filter()
+
+	// Make room below the prompt, scrolling the terminal if needed
+	terminal.Write(strings.Repeat("\r\n", picker.height) + fmt.Sprintf("\x1b[%dA", picker.height))
+	defer terminal.Write("\r" + ESC_CLEAR_DOWN)
+
+	for {
+		picker.Draw()
+		key, resized, err := terminal.NextEvent()
+		if err != nil {
+			return 0, err
+		}
+		if resized {
+			continue
+		}
+
+		switch key.Type {
+		case KEY_ESCAPE:
+			return 0, ErrPickCancelled
+		case KEY_ENTER:
+			if len(picker.matches) > 0 {
+				return picker.matches[picker.cursor], nil
+			}
+		case KEY_UP:
+			picker.MoveCursor(-1)
+		case KEY_DOWN, KEY_TAB:
+			picker.MoveCursor(1)
+		case KEY_PAGE_UP:
+			picker.MoveCursor(-picker.height)
+		case KEY_PAGE_DOWN:
+			picker.MoveCursor(picker.height)
+		case KEY_BACKSPACE:
+			if len(picker.query) > 0 {
+				runes := []rune(picker.query)
+				picker.query = string(runes[0 : len(runes)-1])
+				picker.Filter()
+			}
+		case KEY_CTRL:
+			switch key.Rune {
+			case 'c', 'd', 'g':
+				return 0, ErrPickCancelled
+			case 'n', 'j':
+				picker.MoveCursor(1)
+			case 'p', 'k':
+				picker.MoveCursor(-1)
+			case 'u':
+				picker.query = ""
+				picker.Filter()
+			}
+		case KEY_RUNE:
+			picker.query += string(key.Rune)
+			picker.Filter()
+		}
+	}
+}
+
+func (picker *Picker) Filter() {
+	type match struct {
+		index int
+		score int
+	}
+	found := []match{}
+	for i, item := range picker.items {
+		if score, ok := FuzzyScore(picker.query, item); ok {
+			found = append(found, match{index: i, score: score})
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].score > found[j].score
+	})
+
+	picker.matches = []int{}
+	for _, m := range found {
+		picker.matches = append(picker.matches, m.index)
+	}
+	picker.cursor = 0
+	picker.offset = 0
+}
+
+func (picker *Picker) MoveCursor(delta int) {
+	picker.cursor = max(0, min(picker.cursor+delta, len(picker.matches)-1))
+	if picker.cursor < picker.offset {
+		picker.offset = picker.cursor
+	} else if picker.cursor >= picker.offset+picker.height {
+		picker.offset = picker.cursor - picker.height + 1
+	}
+}
+
+func (picker *Picker) Draw() {
+	width, _ := picker.terminal.Size()
+	counts := fmt.Sprintf(" %d/%d", len(picker.matches), len(picker.items))
+
+	var screen strings.Builder
+	screen.WriteString("\r" + ESC_CLEAR_LINE)
+	screen.WriteString(Truncate(picker.prompt+picker.query, width-len(counts)-1))
+	screen.WriteString(ESC_DIM + counts + ESC_RESET)
+	for row := 0; row < picker.height; row++ {
+		screen.WriteString("\r\n" + ESC_CLEAR_LINE)
+		i := picker.offset + row
+		if i >= len(picker.matches) {
+			continue
+		}
+		line := Truncate(picker.items[picker.matches[i]], width-2)
+		if i == picker.cursor {
+			screen.WriteString(ESC_REVERSE + "> " + line + ESC_RESET)
+		} else {
+			screen.WriteString("  " + line)
+		}
+	}
+
+	// Back up to the prompt line, with the cursor after the query
+	screen.WriteString(fmt.Sprintf("\x1b[%dA\r", picker.height))
+	column := min(StringWidth(picker.prompt+picker.query), width-len(counts)-1)
+	if column > 0 {
+		screen.WriteString(fmt.Sprintf("\x1b[%dC", column))
+	}
+	picker.terminal.Write(screen.String())
+}
+
+// FuzzyScore reports whether every space separated term of pattern appears in
+// text as a subsequence, scoring consecutive and word-start matches higher
+func FuzzyScore(pattern string, text string) (int, bool) {
+	total := 0
+	haystack := []rune(strings.ToLower(text))
+	for _, term := range strings.Fields(strings.ToLower(pattern)) {
+		needle := []rune(term)
+		score := 0
+		n := 0
+		last := -1
+		for i := 0; i < len(haystack) && n < len(needle); i++ {
+			if haystack[i] != needle[n] {
+				continue
+			}
+			score++
+			if last == i-1 {
+				score += 5
+			}
+			if i == 0 || !(unicode.IsLetter(haystack[i-1]) || unicode.IsDigit(haystack[i-1])) {
+				score += 3
+			}
+			if last >= 0 {
+				score -= min(i-last-1, 3)
+			}
+			last = i
+			n++
+		}
+		if n < len(needle) {
+			return 0, false
+		}
+		total += score
+	}
+	return total, true
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		pattern string
+		text    string
+		ok      bool
+	}{
+		{"", "anything", true},
+		{"dep", "Deploy steps", true},
+		{"DEP", "deploy", true},
+		{"dpl", "deploy", true},
+		{"dep steps", "Deploy steps", true},
+		{"steps dep", "Deploy steps", true},
+		{"dep logs", "Deploy steps", false},
+		{"ped", "deploy", false},
+		{"日本", "日x本", true},
+	}
+	for _, test := range tests {
+		if _, ok := FuzzyScore(test.pattern, test.text); ok != test.ok {
+			t.Errorf("FuzzyScore(%q, %q) matches = %v, want %v", test.pattern, test.text, ok, test.ok)
+		}
+	}
+}
+
+func TestFuzzyScoreOrder(t *testing.T) {
+	tests := []struct {
+		pattern string
+		texts   []string // best match first
+	}{
+		// At the start, consecutive, starting a word, then after a gap
+		{"dep", []string{"deploy", "my deploy", "dxexp"}},
+		{"ab", []string{"ab", "a_b", "axb", "axxxxb"}},
+	}
+	for _, test := range tests {
+		last := 0
+		for i, text := range test.texts {
+			score, ok := FuzzyScore(test.pattern, text)
+			if !ok {
+				t.Errorf("FuzzyScore(%q, %q) should match", test.pattern, text)
+			}
+			if i > 0 && score >= last {
+				t.Errorf("FuzzyScore(%q, %q) = %d, want less than %q's %d", test.pattern, text, score, test.texts[i-1], last)
+			}
+			last = score
+		}
+	}
+}
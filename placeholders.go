@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Placeholders look like {name}, {name:default}, {name|description} or
+// {name:default|description}. Shell syntax such as ${var}, {{.Go}} and
+// {1..10} is left alone. Other braces that look like a placeholder, such as
+// awk's '{print}', are escaped as \{print} and filled in without the
+// backslash.
+var placeholderPattern = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_-]*)(?::([^{}|\n]*))?(?:\|([^{}\n]*))?\}`)
+
+type Placeholder struct {
+	Name        string
+	Default     string
+	HasDefault  bool
+	Description string
+}
+
+type placeholderMatch struct {
+	Start       int
+	End         int
+	Escaped     bool
+	Placeholder Placeholder
+}
+
+func findPlaceholderMatches(content string) []placeholderMatch {
+	matches := []placeholderMatch{}
+	for _, indexes := range placeholderPattern.FindAllStringSubmatchIndex(content, -1) {
+		start, end := indexes[0], indexes[1]
+		if end < len(content) && content[end] == '}' {
+			continue
+		}
+		escaped := false
+		if start > 0 {
+			switch content[start-1] {
+			case '$', '{':
+				continue
+			case '\\':
+				escaped = true
+			}
+		}
+
+		placeholder := Placeholder{Name: content[indexes[2]:indexes[3]]}
+		if indexes[4] >= 0 {
+			placeholder.Default = content[indexes[4]:indexes[5]]
+			placeholder.HasDefault = true
+		}
+		if indexes[6] >= 0 {
+			placeholder.Description = strings.TrimSpace(content[indexes[6]:indexes[7]])
+		}
+		matches = append(matches, placeholderMatch{
+			Start:       start,
+			End:         end,
+			Escaped:     escaped,
+			Placeholder: placeholder,
+		})
+	}
+	return matches
+}
+
+// The distinct placeholders in content, in order of first appearance. A
+// default or description given on any occurrence applies to all of them.
+func FindPlaceholders(content string) []Placeholder {
+	placeholders := []Placeholder{}
+	indexes := make(map[string]int)
+	for _, match := range findPlaceholderMatches(content) {
+		if match.Escaped {
+			continue
+		}
+		placeholder := match.Placeholder
+		i, ok := indexes[placeholder.Name]
+		if !ok {
+			indexes[placeholder.Name] = len(placeholders)
+			placeholders = append(placeholders, placeholder)
+			continue
+		}
+		if !placeholders[i].HasDefault && placeholder.HasDefault {
+			placeholders[i].Default = placeholder.Default
+			placeholders[i].HasDefault = true
+		}
+		if placeholders[i].Description == "" {
+			placeholders[i].Description = placeholder.Description
+		}
+	}
+	return placeholders
+}
+
+// Replaces placeholders with their values, leaving any without a value as is
+func FillPlaceholders(content string, values map[string]string) string {
+	var filled strings.Builder
+	last := 0
+	for _, match := range findPlaceholderMatches(content) {
+		if match.Escaped {
+			filled.WriteString(content[last : match.Start-1])
+			filled.WriteString(content[match.Start:match.End])
+		} else if value, ok := values[match.Placeholder.Name]; ok {
+			filled.WriteString(content[last:match.Start])
+			filled.WriteString(value)
+		} else {
+			filled.WriteString(content[last:match.End])
+		}
+		last = match.End
+	}
+	filled.WriteString(content[last:])
+	return filled.String()
+}
+
+// Parses a `--var name=value` argument
+func ParseVar(arg string) (string, string) {
+	name, value, ok := strings.Cut(arg, "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		cliError(fmt.Sprintf("Invalid variable '%s', expected NAME=VALUE", arg))
+	}
+	return name, value
+}
+
+func ParseVars(args []string) map[string]string {
+	vars := make(map[string]string)
+	for _, arg := range args {
+		name, value := ParseVar(arg)
+		vars[name] = value
+	}
+	return vars
+}
+
+// Works out a value for every placeholder in the memo, using vars first and
+// prompting for the rest, with a list to choose from for placeholders that
+// have a suggestions command. When the user can't be prompted only the
+// declared default is used, never the value remembered from last time, and
+// otherwise it exits with EXIT_NO_INPUT. The values are remembered on the
+// memo as the suggestions for next time.
+func (ui *Ui) FillMemo(memo *Memo, vars map[string]string, config *Config) string {
+	placeholders := FindPlaceholders(memo.Content)
+	if len(placeholders) == 0 {
+		// Escaped braces still lose their backslash
+		return FillPlaceholders(memo.Content, nil)
+	}
+
+	values := make(map[string]string)
+	for _, placeholder := range placeholders {
+		if value, ok := vars[placeholder.Name]; ok {
+			values[placeholder.Name] = value
+			continue
+		}
+
+		prompt := placeholder.Name
+		if placeholder.Description != "" {
+			prompt += fmt.Sprintf(" (%s)", placeholder.Description)
+		}
+
+		// Without prompting, the value used last time may not suit this run,
+		// so only the declared default is taken
+		if !ui.CanPrompt() {
+			if !placeholder.HasDefault {
+				ui.RequirePrompt(prompt, fmt.Sprintf("Use (--var) %s=VALUE to give it.", placeholder.Name))
+			}
+			values[placeholder.Name] = placeholder.Default
+			continue
+		}
+
+		suggestion, has_suggestion := memo.LastValues[placeholder.Name]
+		if !has_suggestion && placeholder.HasDefault {
+			suggestion, has_suggestion = placeholder.Default, true
+		}
+
+		if command, ok := memo.Suggestions[placeholder.Name]; ok && ui.TrustSuggestions(memo, placeholder.Name, command) {
+			// Earlier values can be used in the command, e.g. {container}
+			candidates, err := Suggestions(config, FillPlaceholders(command, values))
+			if err != nil {
+				fmt.Fprintf(ui.Out, "No suggestions for %s: %v\n", placeholder.Name, err)
+			} else if len(candidates) > 0 {
+				values[placeholder.Name] = ui.ChooseValue(prompt, candidates, suggestion, has_suggestion)
+				continue
+			}
+		}
+
+		if has_suggestion {
+			prompt += fmt.Sprintf(" [%s]", suggestion)
+			value := ui.GetText(prompt+": ", "")
+			if value == "" {
+				value = suggestion
+			}
+			values[placeholder.Name] = value
+		} else {
+			values[placeholder.Name] = ui.GetText(prompt+": ", "A value is required: ")
+		}
+	}
+
+	if memo.LastValues == nil {
+		memo.LastValues = make(map[string]string)
+	}
+	for name, value := range values {
+		memo.LastValues[name] = value
+	}
+	// Only suggestions, so not worth failing over
+	if _, err := memo.Write(); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not remember the values for '%s': %v\n", memo.Title, err)
+	}
+
+	return FillPlaceholders(memo.Content, values)
+}
+
+// Whether a placeholder's suggestions command can be run. A project's memos
+// come with its checkout, so their commands are shown and only run once the
+// user agrees, otherwise the value is typed.
+func (ui *Ui) TrustSuggestions(memo *Memo, name string, command string) bool {
+	if StoreName(memo.Dir) != STORE_LOCAL {
+		return true
+	}
+	fmt.Fprintf(ui.Out, "Suggestions for %s from the project's %s directory: %s\n", name, LOCAL_DIR, command)
+	return ui.GetResponse("Run it? (y/n) ", "Try again: ", []string{"y", "n"}) == "y"
+}
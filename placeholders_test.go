@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFindPlaceholders(t *testing.T) {
+	tests := []struct {
+		content string
+		want    []Placeholder
+	}{
+		{"", []Placeholder{}},
+		{"kill {pid}", []Placeholder{{Name: "pid"}}},
+		{"kill $(lsof -t -i:{port:3001})", []Placeholder{{Name: "port", Default: "3001", HasDefault: true}}},
+		{"{port|Port to free}", []Placeholder{{Name: "port", Description: "Port to free"}}},
+		{"{port:3001| Port to free }", []Placeholder{{Name: "port", Default: "3001", HasDefault: true, Description: "Port to free"}}},
+		{"{empty:}", []Placeholder{{Name: "empty", HasDefault: true}}},
+		// A default or description on a later occurrence applies to all
+		{"{a} {b} {a:1|First}", []Placeholder{{Name: "a", Default: "1", HasDefault: true, Description: "First"}, {Name: "b"}}},
+		// Shell and template syntax, and escapes, aren't placeholders
+		{"echo ${HOME} {{.Names}} {1..10} \\{name} {a}}", []Placeholder{}},
+		// Other braces around a word are, unless escaped
+		{"awk '{print}'", []Placeholder{{Name: "print"}}},
+		{"awk '\\{print}'", []Placeholder{}},
+	}
+	for _, test := range tests {
+		if got := FindPlaceholders(test.content); !slices.Equal(got, test.want) {
+			t.Errorf("FindPlaceholders(%q) = %+v, want %+v", test.content, got, test.want)
+		}
+	}
+}
+
+func TestFillPlaceholders(t *testing.T) {
+	tests := []struct {
+		content string
+		values  map[string]string
+		want    string
+	}{
+		{"kill {pid}", map[string]string{"pid": "42"}, "kill 42"},
+		{"{port:3001}", map[string]string{"port": "80"}, "80"},
+		{"{a} and {b}", map[string]string{"a": "x"}, "x and {b}"},
+		{"{a}{a|again}", map[string]string{"a": "x"}, "xx"},
+		{"\\{name} {name}", map[string]string{"name": "v"}, "{name} v"},
+		{"${HOME}/{dir}", map[string]string{"dir": "d", "HOME": "no"}, "${HOME}/d"},
+		{"no placeholders", nil, "no placeholders"},
+		{"awk '\\{print}' {file}", map[string]string{"file": "f"}, "awk '{print}' f"},
+	}
+	for _, test := range tests {
+		if got := FillPlaceholders(test.content, test.values); got != test.want {
+			t.Errorf("FillPlaceholders(%q, %v) = %q, want %q", test.content, test.values, got, test.want)
+		}
+	}
+}
+
+func TestFillMemoWithoutPlaceholders(t *testing.T) {
+	ui := &Ui{}
+	memo := &Memo{Content: "awk '\\{print}' notes.txt"}
+	if got, want := ui.FillMemo(memo, nil, nil), "awk '{print}' notes.txt"; got != want {
+		t.Errorf("FillMemo() = %q, want %q", got, want)
+	}
+}
+
+func TestFillMemoWithoutPrompting(t *testing.T) {
+	ui := &Ui{Interactive: false}
+	memo := &Memo{
+		Title:      "Connect",
+		Content:    "ssh {host} -p {port:22}",
+		LastValues: map[string]string{"host": "old", "port": "2222"},
+		Dir:        t.TempDir(),
+	}
+	// The value remembered from last time is never used
+	if got, want := ui.FillMemo(memo, map[string]string{"host": "new"}, &Config{}), "ssh new -p 22"; got != want {
+		t.Errorf("FillMemo() = %q, want %q", got, want)
+	}
+}
+
+func TestFillMemoLocalSuggestions(t *testing.T) {
+	defer func(dir string) { local_dir = dir }(local_dir)
+	local_dir = t.TempDir()
+	if err := os.MkdirAll(LocalSavesDir(), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	marker := filepath.Join(t.TempDir(), "ran")
+	memo := &Memo{
+		Title:       "Logs",
+		Content:     "docker logs {container}",
+		Suggestions: map[string]string{"container": "touch " + ShellQuote(marker)},
+		Dir:         LocalSavesDir(),
+	}
+	ui := &Ui{
+		Scanner:     bufio.NewScanner(strings.NewReader("n\nweb\n")),
+		Out:         io.Discard,
+		Interactive: true,
+	}
+	// Declining the project's command falls back to typing the value
+	if got, want := ui.FillMemo(memo, nil, &Config{}), "docker logs web"; got != want {
+		t.Errorf("FillMemo() = %q, want %q", got, want)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Errorf("the suggestions command ran without being agreed to")
+	}
+}
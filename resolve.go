@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Commands name a memo by an IDENTIFIER, which is tried as, in order:
+// its exact title, a prefix of its hash at least MIN_HASH_PREFIX long, its
+// title ignoring case, then the start of its title ignoring case.
+
+const MIN_HASH_PREFIX = 4
+
+const IDENTIFIER_HELP = "IDENTIFIER is the memo's title, the start of its title or at least 4 characters of its hash."
+
+var ErrMemoNotFound = errors.New("no memo matches")
+
+type AmbiguousError struct {
+	Identifier string
+	Hashes     []HASH // sorted by title
+}
+
+func (err *AmbiguousError) Error() string {
+	return fmt.Sprintf("%d memos match '%s'", len(err.Hashes), err.Identifier)
+}
+
+// The hash of the one memo identifier names, otherwise ErrMemoNotFound or
+// an *AmbiguousError
+func FindMemo(memos map[HASH]*Memo, identifier string) (HASH, error) {
+	if identifier == "" {
+		return "", ErrMemoNotFound
+	}
+	lower := strings.ToLower(identifier)
+	matchers := []func(hash HASH, memo *Memo) bool{
+		func(hash HASH, memo *Memo) bool { return memo.Title == identifier },
+		func(hash HASH, memo *Memo) bool {
+			return len(identifier) >= MIN_HASH_PREFIX && strings.HasPrefix(hash, lower)
+		},
+		func(hash HASH, memo *Memo) bool { return strings.ToLower(memo.Title) == lower },
+		func(hash HASH, memo *Memo) bool { return strings.HasPrefix(strings.ToLower(memo.Title), lower) },
+	}
+
+	for _, matches := range matchers {
+		found := []HASH{}
+		for hash, memo := range memos {
+			if matches(hash, memo) {
+				found = append(found, hash)
+			}
+		}
+		if len(found) == 1 {
+			return found[0], nil
+		} else if len(found) > 1 {
+			// A local and a global memo can share a title, the global one first
+			sort.Slice(found, func(i, j int) bool {
+				a, b := memos[found[i]], memos[found[j]]
+				if strings.ToLower(a.Title) == strings.ToLower(b.Title) {
+					return StoreName(a.Dir) < StoreName(b.Dir)
+				}
+				return strings.ToLower(a.Title) < strings.ToLower(b.Title)
+			})
+			return "", &AmbiguousError{Identifier: identifier, Hashes: found}
+		}
+	}
+	return "", ErrMemoNotFound
+}
+
+// FindMemo for commands, exiting if no memo matches. When several do, they
+// are offered to pick from on the terminal, or listed if there is none or
+// with --non-interactive.
+func (ui *Ui) ResolveMemo(memos map[HASH]*Memo, identifier string) (HASH, *Memo) {
+	hash, err := FindMemo(memos, identifier)
+	if err == nil {
+		return hash, memos[hash]
+	}
+
+	var ambiguous *AmbiguousError
+	if !errors.As(err, &ambiguous) {
+		dataError(fmt.Sprintf("Unknown memo identifier '%s'", identifier), EXIT_NOT_FOUND)
+	}
+
+	if !non_interactive && term.IsTerminal(int(os.Stderr.Fd())) {
+		items := []string{}
+		for _, hash := range ambiguous.Hashes {
+			items = append(items, MemoPickerLabel(hash, memos[hash]))
+		}
+		fmt.Fprintf(ui.Out, "'%s' matches %d memos, choose one:\n", identifier, len(ambiguous.Hashes))
+		index, pick_err := Pick("> ", items, "")
+		if errors.Is(pick_err, ErrPickCancelled) {
+			os.Exit(EXIT_ABORTED)
+		} else if pick_err == nil {
+			hash := ambiguous.Hashes[index]
+			return hash, memos[hash]
+		}
+	}
+
+	message := fmt.Sprintf("Memo identifier '%s' is ambiguous, matching:", identifier)
+	for _, hash := range ambiguous.Hashes {
+		message += fmt.Sprintf("\n    %s  %s", hash[0:8], memos[hash].Title)
+		if local_dir != "" {
+			message += " (" + StoreName(memos[hash].Dir) + ")"
+		}
+	}
+	dataError(message, EXIT_AMBIGUOUS)
+	return "", nil
+}
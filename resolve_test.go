@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestFindMemo(t *testing.T) {
+	memos := map[HASH]*Memo{
+		"abcd1111": {Title: "Deploy steps"},
+		"abce2222": {Title: "deploy staging"},
+		"ff003333": {Title: "Backup"},
+		"ff014444": {Title: "Backup old"},
+		"99995555": {Title: "abce notes"},
+	}
+	tests := []struct {
+		identifier string
+		hash       HASH
+		ambiguous  []HASH // sorted by title, nil if not ambiguous
+	}{
+		{"Deploy steps", "abcd1111", nil},
+		{"deploy STEPS", "abcd1111", nil},
+		{"deploy sta", "abce2222", nil},
+		{"dep", "", []HASH{"abce2222", "abcd1111"}},
+		// An exact title comes before one ignoring case, then a prefix
+		{"Backup", "ff003333", nil},
+		{"backup", "ff003333", nil},
+		{"back", "", []HASH{"ff003333", "ff014444"}},
+		// A hash prefix comes before a title, but only from 4 characters
+		{"ff00", "ff003333", nil},
+		{"ABCD", "abcd1111", nil},
+		{"abce", "abce2222", nil},
+		{"abc", "99995555", nil},
+		{"ff0", "", nil},
+		{"zzz", "", nil},
+		{"", "", nil},
+	}
+	for _, test := range tests {
+		hash, err := FindMemo(memos, test.identifier)
+		var ambiguous *AmbiguousError
+		switch {
+		case test.ambiguous != nil:
+			if !errors.As(err, &ambiguous) || !slices.Equal(ambiguous.Hashes, test.ambiguous) {
+				t.Errorf("FindMemo(%q) = %q, %v, want ambiguous %q", test.identifier, hash, err, test.ambiguous)
+			}
+		case test.hash == "":
+			if !errors.Is(err, ErrMemoNotFound) {
+				t.Errorf("FindMemo(%q) = %q, %v, want ErrMemoNotFound", test.identifier, hash, err)
+			}
+		case hash != test.hash || err != nil:
+			t.Errorf("FindMemo(%q) = %q, %v, want %q", test.identifier, hash, err, test.hash)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+func RunMemo(ui *Ui, config *Config, args *Args) {
+	identifier := args.Arg(0)
+	auto_confirm := args.Has("yes")
+	step := args.Has("step")
+	extra_args := args.Extra
+	vars := ParseVars(args.All("var"))
+
+	if identifier == "" {
+		cliError("No memo hash/title given")
+	}
+	if step && len(extra_args) > 0 {
+		cliError("Extra arguments can't be used with (-s/--step)")
+	}
+
+	memos := LoadAllMemos(config)
+	_, memo_to_run := ui.ResolveMemo(memos, identifier)
+	if memo_to_run.NoRun {
+		dataError(fmt.Sprintf("Memo '%s' is not runnable. Use `%s %s --runnable '%s'` to allow it.", memo_to_run.Title, APP_NAME, CMD_EDIT, memo_to_run.Title))
+	}
+	if !IsRunnable(memo_to_run) {
+		dataError(fmt.Sprintf("Memo '%s' is %s, which can't be run. Use `%s %s --type sh '%s'` if it is a command.", memo_to_run.Title, memo_to_run.Type, APP_NAME, CMD_EDIT, memo_to_run.Title))
+	}
+
+	if step {
+		steps := ParseSteps(ui.FillMemo(memo_to_run, vars, config), memo_to_run.Type)
+		if len(steps) == 0 {
+			dataError(fmt.Sprintf("Memo '%s' has no steps to run", memo_to_run.Title))
+		}
+		os.Exit(ui.RunSteps(config, steps, memo_to_run.Type, auto_confirm))
+	}
+
+	command := AppendArgs(strings.TrimSpace(memo_to_run.Content), extra_args)
+	if command == "" {
+		dataError(fmt.Sprintf("Memo '%s' has no content to run", memo_to_run.Title))
+	}
+
+	// Confirmed with the placeholders unfilled, as filling them can run the
+	// memo's suggestion commands
+	if !auto_confirm {
+		ui.RequirePrompt("Run?", "Use (-y/--yes) to run it without asking.")
+		fmt.Fprintln(ui.Out, command)
+		fmt.Fprintln(ui.Out)
+		response := ui.GetResponse(
+			"Run? (y/n) ",
+			"Try again: ",
+			[]string{"y", "n"},
+		)
+		if response == "n" {
+			dataError("Not run", EXIT_ABORTED)
+		}
+	}
+	command = AppendArgs(strings.TrimSpace(ui.FillMemo(memo_to_run, vars, config)), extra_args)
+
+	os.Exit(RunCommand(config, command, memo_to_run.Type))
+}
+
+// Runs command with the terminal attached and returns its exit code. Shell
+// commands go through the configured shell, other types their interpreter.
+func RunCommand(config *Config, command string, memo_type string) int {
+	shell, flag := GetShell(config)
+	if language := GetLanguage(memo_type); language != nil && language.Interpreter != nil {
+		shell, flag = language.Interpreter[0], language.Interpreter[1]
+	}
+	debugf("Running %s %s %s", shell, flag, ShellQuote(command))
+	cmd := exec.Command(shell, flag, command)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	err := cmd.Run()
+	var exit_error *exec.ExitError
+	if errors.As(err, &exit_error) {
+		return exit_error.ExitCode()
+	} else if err != nil {
+		dataError(fmt.Sprintf("Could not run '%s': %v", shell, err))
+	}
+	return 0
+}
+
+// The shell from the config, then $SHELL, then the system default, along
+// with the flag it takes to run a command string
+func GetShell(config *Config) (string, string) {
+	shell := strings.TrimSpace(config.Shell)
+	if shell == "" {
+		shell = strings.TrimSpace(os.Getenv("SHELL"))
+	}
+	if shell == "" {
+		if runtime.GOOS == "windows" {
+			shell = "cmd"
+		} else {
+			shell = "/bin/sh"
+		}
+	}
+
+	name := strings.TrimSuffix(strings.ToLower(filepath.Base(shell)), ".exe")
+	switch name {
+	case "cmd":
+		return shell, "/C"
+	case "powershell", "pwsh":
+		return shell, "-Command"
+	}
+	return shell, "-c"
+}
+
+// Adds args, quoted, to the end of the last line of command
+func AppendArgs(command string, args []string) string {
+	if len(args) == 0 {
+		return command
+	}
+	quoted := []string{}
+	for _, arg := range args {
+		quoted = append(quoted, ShellQuote(arg))
+	}
+	return command + " " + strings.Join(quoted, " ")
+}
+
+// Splits a command line into words the way a POSIX shell would, handling
+// quotes and backslashes but nothing else
+func ShellWords(line string) ([]string, error) {
+	words := []string{}
+	var word strings.Builder
+	in_word := false
+	quote := rune(0)
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\' && quote != '\'':
+			// Inside double quotes only a few characters can be escaped,
+			// which keeps Windows paths intact
+			if i+1 == len(runes) {
+				return nil, errors.New("trailing backslash")
+			}
+			if quote == 0 || strings.ContainsRune("\"\\$`", runes[i+1]) {
+				i++
+			}
+			word.WriteRune(runes[i])
+			in_word = true
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote = r
+			in_word = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if in_word {
+				words = append(words, word.String())
+				word.Reset()
+				in_word = false
+			}
+		default:
+			word.WriteRune(r)
+			in_word = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if in_word {
+		words = append(words, word.String())
+	}
+	if len(words) == 0 {
+		return nil, errors.New("no command")
+	}
+	return words, nil
+}
+
+func ShellQuote(arg string) string {
+	if arg != "" && strings.IndexFunc(arg, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./=:,+@%", r))
+	}) == -1 {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
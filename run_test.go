@@ -0,0 +1,64 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestShellWords(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string // nil when an error is expected
+	}{
+		{"vim", []string{"vim"}},
+		{"  emacs   -nw  ", []string{"emacs", "-nw"}},
+		{"code --wait", []string{"code", "--wait"}},
+		{`"/Applications/My Editor" -w`, []string{"/Applications/My Editor", "-w"}},
+		{`'it'\''s'`, []string{"it's"}},
+		{`a\ b`, []string{"a b"}},
+		{`"C:\Program Files\x"`, []string{`C:\Program Files\x`}},
+		{`"say \"hi\" \$HOME"`, []string{`say "hi" $HOME`}},
+		{`'a\b'`, []string{`a\b`}},
+		{`a""b ''`, []string{"ab", ""}},
+		{"a\tb\nc", []string{"a", "b", "c"}},
+		{"", nil},
+		{"   ", nil},
+		{`"open`, nil},
+		{`'open`, nil},
+		{`a\`, nil},
+	}
+	for _, test := range tests {
+		got, err := ShellWords(test.line)
+		if test.want == nil {
+			if err == nil {
+				t.Errorf("ShellWords(%q) = %q, expected an error", test.line, got)
+			}
+		} else if err != nil || !slices.Equal(got, test.want) {
+			t.Errorf("ShellWords(%q) = %q, %v, want %q", test.line, got, err, test.want)
+		}
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want string
+	}{
+		{"abc", "abc"},
+		{"-la/tmp=1", "-la/tmp=1"},
+		{"", "''"},
+		{"a b", "'a b'"},
+		{"it's", `'it'\''s'`},
+		{"$HOME", "'$HOME'"},
+	}
+	for _, test := range tests {
+		got := ShellQuote(test.arg)
+		if got != test.want {
+			t.Errorf("ShellQuote(%q) = %q, want %q", test.arg, got, test.want)
+		}
+		// Quoted arguments split back into themselves
+		if words, err := ShellWords("cmd " + got); err != nil || !slices.Equal(words, []string{"cmd", test.arg}) {
+			t.Errorf("ShellWords(%q) = %q, %v, want the quoted argument back", "cmd "+got, words, err)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A runbook memo is a sequence of commands, one per line, each described by
+// the comment lines directly above it:
+//
+//	# Stop new pods being scheduled
+//	kubectl cordon {node}
+//	# Move everything off it
+//	kubectl drain {node} --ignore-daemonsets
+//
+// Lines ending in a backslash continue onto the next line.
+type Step struct {
+	Description string
+	Command     string
+}
+
+const (
+	STEP_PENDING   = "not run"
+	STEP_SUCCEEDED = "ok"
+	STEP_FAILED    = "failed"
+	STEP_SKIPPED   = "skipped"
+)
+
+type StepResult struct {
+	Step     Step
+	Status   string
+	ExitCode int
+}
+
+func ParseSteps(content string, memo_type string) []Step {
+	comment := "#"
+	if language := GetLanguage(memo_type); language != nil && language.LineComment != "" {
+		comment = language.LineComment
+	}
+
+	steps := []Step{}
+	description := []string{}
+	command := []string{}
+	for i, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if len(command) > 0 {
+			command = append(command, line)
+			if !strings.HasSuffix(trimmed, "\\") {
+				steps = append(steps, Step{
+					Description: strings.Join(description, " "),
+					Command:     strings.Join(command, "\n"),
+				})
+				description, command = []string{}, []string{}
+			}
+			continue
+		}
+
+		switch {
+		case i == 0 && strings.HasPrefix(trimmed, "#!"):
+		case trimmed == "":
+			description = []string{}
+		case strings.HasPrefix(trimmed, comment):
+			description = append(description, strings.TrimSpace(strings.TrimPrefix(trimmed, comment)))
+		case strings.HasSuffix(trimmed, "\\"):
+			command = append(command, trimmed)
+		default:
+			steps = append(steps, Step{
+				Description: strings.Join(description, " "),
+				Command:     trimmed,
+			})
+			description = []string{}
+		}
+	}
+	if len(command) > 0 {
+		steps = append(steps, Step{
+			Description: strings.Join(description, " "),
+			Command:     strings.Join(command, "\n"),
+		})
+	}
+	return steps
+}
+
+// Walks through the steps one at a time, asking whether to run, skip or abort
+// each one and whether to retry those that fail. With auto_confirm every step
+// is run, stopping at the first failure. Returns the exit code for the run as
+// a whole, 0 only if no step failed and none were left. A failed step that
+// is then skipped counts as skipped, so it doesn't fail the run.
+func (ui *Ui) RunSteps(config *Config, steps []Step, memo_type string, auto_confirm bool) int {
+	results := []StepResult{}
+	for _, step := range steps {
+		results = append(results, StepResult{Step: step, Status: STEP_PENDING})
+	}
+
+	if !auto_confirm {
+		ui.RequirePrompt("(r)un, (s)kip or (a)bort?", "Use (-y/--yes) to run every step, stopping at the first failure.")
+	}
+
+	aborted := false
+	for i := 0; i < len(results) && !aborted; i++ {
+		result := &results[i]
+		if !auto_confirm || verbosity > VERBOSITY_QUIET {
+			fmt.Fprintln(ui.Out)
+			if result.Step.Description != "" {
+				fmt.Fprintf(ui.Out, "Step %d/%d: %s\n", i+1, len(results), result.Step.Description)
+			} else {
+				fmt.Fprintf(ui.Out, "Step %d/%d\n", i+1, len(results))
+			}
+			fmt.Fprintln(ui.Out, result.Step.Command)
+		}
+
+		response := "r"
+		if !auto_confirm {
+			response = ui.GetResponse(
+				"(r)un, (s)kip or (a)bort? ",
+				"Try again: ",
+				[]string{"r", "s", "a"},
+			)
+		}
+		for response == "r" {
+			result.ExitCode = RunCommand(config, result.Step.Command, memo_type)
+			if result.ExitCode == 0 {
+				result.Status = STEP_SUCCEEDED
+				break
+			}
+			result.Status = STEP_FAILED
+			fmt.Fprintf(ui.Out, "Step %d failed with exit code %d\n", i+1, result.ExitCode)
+			if auto_confirm {
+				response = "a"
+				break
+			}
+			response = ui.GetResponse(
+				"(r)etry, (s)kip or (a)bort? ",
+				"Try again: ",
+				[]string{"r", "s", "a"},
+			)
+		}
+		if response == "s" {
+			result.Status = STEP_SKIPPED
+		}
+		aborted = response == "a"
+	}
+
+	if verbosity > VERBOSITY_QUIET {
+		PrintStepSummary(ui.Out, results)
+	}
+
+	for _, result := range results {
+		if result.Status == STEP_FAILED {
+			return result.ExitCode
+		}
+	}
+	if aborted {
+		return EXIT_ABORTED
+	}
+	return 0
+}
+
+func PrintStepSummary(out io.Writer, results []StepResult) {
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Summary:")
+	counts := make(map[string]int)
+	for i, result := range results {
+		// Including steps skipped after failing
+		status := result.Status
+		if result.ExitCode != 0 {
+			status = fmt.Sprintf("%s (%d)", status, result.ExitCode)
+		}
+		name := result.Step.Description
+		if name == "" {
+			name, _, _ = strings.Cut(result.Step.Command, "\n")
+		}
+		fmt.Fprintf(out, "%3d) %-12s %s\n", i+1, status, name)
+		counts[result.Status]++
+	}
+	fmt.Fprintf(
+		out,
+		"%d succeeded, %d failed, %d skipped, %d not run\n",
+		counts[STEP_SUCCEEDED],
+		counts[STEP_FAILED],
+		counts[STEP_SKIPPED],
+		counts[STEP_PENDING],
+	)
+}
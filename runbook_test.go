@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseSteps(t *testing.T) {
+	tests := []struct {
+		content   string
+		memo_type string
+		want      []Step
+	}{
+		{"", "", []Step{}},
+		{
+			"# Stop new pods\nkubectl cordon n\n# Move everything\n# off it\nkubectl drain n",
+			"sh",
+			[]Step{{"Stop new pods", "kubectl cordon n"}, {"Move everything off it", "kubectl drain n"}},
+		},
+		{"#!/bin/sh\necho a", "sh", []Step{{"", "echo a"}}},
+		// A blank line ends a description
+		{"# Lost\n\necho a", "", []Step{{"", "echo a"}}},
+		{"# Build\nmake \\\n  all\necho done", "", []Step{{"Build", "make \\\n  all"}, {"", "echo done"}}},
+		{"echo a \\", "", []Step{{"", "echo a \\"}}},
+		{"// Install\nnpm ci", "javascript", []Step{{"Install", "npm ci"}}},
+	}
+	for _, test := range tests {
+		if got := ParseSteps(test.content, test.memo_type); !slices.Equal(got, test.want) {
+			t.Errorf("ParseSteps(%q, %q) = %+v, want %+v", test.content, test.memo_type, got, test.want)
+		}
+	}
+}
+
+func TestRunSteps(t *testing.T) {
+	steps := []Step{{"Fails", "exit 3"}, {"Works", "exit 0"}}
+	tests := []struct {
+		input        string
+		auto_confirm bool
+		want         int
+	}{
+		{"r\nr\na\n", false, 3},
+		{"s\ns\n", false, 0},
+		// A failed step that is skipped doesn't fail the run
+		{"r\ns\nr\n", false, 0},
+		{"r\nr\ns\nr\n", false, 0},
+		{"r\na\n", false, 3},
+		{"s\na\n", false, EXIT_ABORTED},
+		{"", true, 3},
+	}
+	for _, test := range tests {
+		ui := &Ui{
+			Scanner:     bufio.NewScanner(strings.NewReader(test.input)),
+			Out:         io.Discard,
+			Interactive: true,
+		}
+		if got := ui.RunSteps(&Config{}, steps, "", test.auto_confirm); got != test.want {
+			t.Errorf("RunSteps() answering %q = %d, want %d", test.input, got, test.want)
+		}
+	}
+}
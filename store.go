@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Memos are kept in the global store, the notebook in use, and in a
+// project's local store when the working directory is inside one: a .memo
+// directory found by walking up like git does, holding saves/ and an
+// optional memo.conf whose settings are merged over the user's.
+
+const (
+	LOCAL_DIR = ".memo"
+
+	STORE_GLOBAL = "global"
+	STORE_LOCAL  = "local"
+)
+
+// Choose the store listed
+var STORE_FLAGS = []Flag{
+	{Long: "global", Help: "Only the memos in the global store, the notebook in use."},
+	{Long: "local", Help: "Only the memos in the project's " + LOCAL_DIR + " directory."},
+}
+
+// Choose the store a new memo is saved in
+var NEW_MEMO_STORE_FLAGS = []Flag{
+	{Long: "global", Help: "Saves the memo in the global store, the notebook in use."},
+	{Long: "local", Help: "Saves the memo in the project's " + LOCAL_DIR + " directory."},
+}
+
+// The .memo directory of the project the working directory is in, or empty
+var local_dir string
+
+// Walks up from dir to the first directory containing a .memo directory
+func FindLocalDir(dir string) string {
+	home, _ := os.UserHomeDir()
+	for {
+		candidate := filepath.Join(dir, LOCAL_DIR)
+		// The home directory's is left out, as every project would have it
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() && dir != home {
+			return candidate
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+func LocalSavesDir() string {
+	if local_dir == "" {
+		return ""
+	}
+	return filepath.Join(local_dir, SAVES_DIR)
+}
+
+func LocalConfigPath() string {
+	if local_dir == "" {
+		return ""
+	}
+	return filepath.Join(local_dir, "memo.conf")
+}
+
+// The name of the store a memo in saves_dir belongs to
+func StoreName(saves_dir string) string {
+	if saves_dir != "" && saves_dir == LocalSavesDir() {
+		return STORE_LOCAL
+	}
+	return STORE_GLOBAL
+}
+
+// Exits unless there is a local store
+func RequireLocalDir() {
+	if local_dir == "" {
+		dataError(fmt.Sprintf("No %s directory in '%s' or above it. Create one with `mkdir %s` at the project's root.", LOCAL_DIR, WorkingDir(), LOCAL_DIR))
+	}
+}
+
+func WorkingDir() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "."
+	}
+	return dir
+}
+
+// The memos of every store, or the one chosen by (--global) or (--local).
+// Titles are only unique within a store, so a local and a global memo can
+// share one, and are told apart by their hashes.
+func LoadStores(config *Config, args *Args) map[HASH]*Memo {
+	global, local := true, local_dir != ""
+	if args != nil && args.Has("global") && args.Has("local") {
+		cliError("Only one of (--global) and (--local) can be given")
+	} else if args != nil && args.Has("global") {
+		local = false
+	} else if args != nil && args.Has("local") {
+		RequireLocalDir()
+		global = false
+	}
+
+	memos := make(map[HASH]*Memo)
+	if global {
+		memos = LoadMemos(GlobalSavesDir(config))
+	}
+	if local {
+		for hash, memo := range LoadMemos(LocalSavesDir()) {
+			memos[hash] = memo
+		}
+	}
+	return memos
+}
+
+// Every memo, from all the stores
+func LoadAllMemos(config *Config) map[HASH]*Memo {
+	return LoadStores(config, nil)
+}
+
+// Where a new memo goes: the store chosen by (--global) or (--local),
+// otherwise the `DefaultStore` setting
+func NewMemoDir(config *Config, args *Args) string {
+	store := config.DefaultStore
+	if args.Has("global") && args.Has("local") {
+		cliError("Only one of (--global) and (--local) can be given")
+	} else if args.Has("global") {
+		store = STORE_GLOBAL
+	} else if args.Has("local") {
+		RequireLocalDir()
+		store = STORE_LOCAL
+	}
+	if store == STORE_LOCAL && local_dir != "" {
+		return LocalSavesDir()
+	}
+	return GlobalSavesDir(config)
+}
+
+// Creates the stores' directories
+func CreateStores(config *Config) {
+	dirs := []string{config.SavesDir}
+	if local_dir != "" {
+		dirs = append(dirs, LocalSavesDir())
+	}
+	for _, dir := range dirs {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			dataError(fmt.Sprintf("Could not create the saves directory '%s': %v", dir, err), EXIT_STORAGE)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	DEFAULT_SUGGEST_TIMEOUT   = 5   // seconds
+	DEFAULT_SUGGEST_CACHE_TTL = 300 // seconds
+)
+
+type suggestionCacheEntry struct {
+	Values []string
+	Time   time.Time
+}
+
+// Suggestions are cached per shell session, which is the parent process
+// unless MEMO_SESSION says otherwise, in the user's own cache directory.
+// Empty if there is none, and nothing is cached.
+func suggestionCachePath() string {
+	cache_dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	dir := filepath.Join(cache_dir, APP_NAME)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return ""
+	}
+	session := strings.TrimSpace(os.Getenv("MEMO_SESSION"))
+	if session == "" {
+		session = fmt.Sprintf("%d", os.Getppid())
+	}
+	return filepath.Join(dir, fmt.Sprintf("suggestions-%s.json", ToFilename(session, "")))
+}
+
+// The cached suggestions by command. A file the user doesn't own is
+// ignored, as its values would be offered as if they came from the command.
+func readSuggestionCache(cache_path string) map[string]suggestionCacheEntry {
+	cache := make(map[string]suggestionCacheEntry)
+	file, err := os.Open(cache_path)
+	if err != nil {
+		return cache
+	}
+	defer file.Close()
+	if info, err := file.Stat(); err != nil || !info.Mode().IsRegular() || !IsOwnFile(info) {
+		debugf("Ignored suggestion cache '%s' as it isn't the user's own file", cache_path)
+		return cache
+	}
+	if FromJsonFile(&cache, file) != nil {
+		return make(map[string]suggestionCacheEntry)
+	}
+	return cache
+}
+
+// Writes the cache readable by the user only. It is renamed into place so
+// the file is never seen half written, and a link at cache_path is replaced
+// rather than followed.
+func writeSuggestionCache(cache map[string]suggestionCacheEntry, cache_path string) error {
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Created with 0600
+	file, err := os.CreateTemp(filepath.Dir(cache_path), ".suggestions-*")
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if close_err := file.Close(); err == nil {
+		err = close_err
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), cache_path)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+	}
+	return err
+}
+
+// Runs command through the shell and returns each non-empty line of its
+// output as a candidate value
+func Suggestions(config *Config, command string) ([]string, error) {
+	cache_path := suggestionCachePath()
+	cache := readSuggestionCache(cache_path)
+
+	ttl := config.SuggestCacheTTL
+	if ttl == 0 {
+		ttl = DEFAULT_SUGGEST_CACHE_TTL
+	}
+	if entry, ok := cache[command]; ok && time.Since(entry.Time) < time.Duration(ttl)*time.Second {
+		return entry.Values, nil
+	}
+
+	timeout := config.SuggestTimeout
+	if timeout == 0 {
+		timeout = DEFAULT_SUGGEST_TIMEOUT
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	shell, flag := GetShell(config)
+	cmd := exec.CommandContext(ctx, shell, flag, command)
+	// Don't wait on children that outlive the shell after a timeout
+	cmd.WaitDelay = time.Second
+	output, err := cmd.Output()
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("'%s' timed out after %ds", command, timeout)
+	}
+	var exit_error *exec.ExitError
+	if errors.As(err, &exit_error) {
+		return nil, fmt.Errorf("'%s' failed: %s", command, strings.TrimSpace(string(exit_error.Stderr)))
+	} else if err != nil {
+		return nil, err
+	}
+
+	values := []string{}
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !seen[line] {
+			seen[line] = true
+			values = append(values, line)
+		}
+	}
+
+	cache[command] = suggestionCacheEntry{Values: values, Time: time.Now()}
+	if cache_path != "" {
+		if err := writeSuggestionCache(cache, cache_path); err != nil {
+			debugf("Could not cache suggestions in '%s': %v", cache_path, err)
+		}
+	}
+	return values, nil
+}
+
+// Lets the user choose one of values. An empty answer gives suggestion, a
+// number picks from the list and anything else is used as typed.
+func (ui *Ui) ChooseValue(prompt string, values []string, suggestion string, has_suggestion bool) string {
+	if index, err := Pick(prompt+": ", values, ""); err == nil {
+		return values[index]
+	} else if errors.Is(err, ErrPickCancelled) {
+		// Fall through to typing a value in
+		values = nil
+	}
+
+	for i, value := range values {
+		fmt.Fprintf(ui.Out, "%3d) %s\n", i+1, value)
+	}
+	if has_suggestion {
+		prompt += fmt.Sprintf(" [%s]", suggestion)
+	}
+	for {
+		text := ui.GetText(prompt+": ", "")
+		if text == "" && has_suggestion {
+			return suggestion
+		}
+		var choice int
+		if _, err := fmt.Sscanf(text, "%d", &choice); err == nil && fmt.Sprint(choice) == text {
+			if choice >= 1 && choice <= len(values) {
+				return values[choice-1]
+			}
+		}
+		if text != "" {
+			return text
+		}
+		fmt.Fprint(ui.Out, "A value is required. ")
+	}
+}
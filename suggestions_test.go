@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSuggestionCache(t *testing.T) {
+	dir := t.TempDir()
+	cache_path := filepath.Join(dir, "suggestions.json")
+	if cache := readSuggestionCache(cache_path); len(cache) != 0 {
+		t.Errorf("readSuggestionCache() of a missing file = %v, want it empty", cache)
+	}
+
+	// A link is replaced rather than written through
+	target := filepath.Join(dir, "target")
+	if err := os.WriteFile(target, []byte("kept"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, cache_path); err != nil {
+		t.Fatal(err)
+	}
+
+	cache := map[string]suggestionCacheEntry{"ls": {Values: []string{"a", "b"}, Time: time.Now()}}
+	if err := writeSuggestionCache(cache, cache_path); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "kept" {
+		t.Errorf("writeSuggestionCache() wrote %q through a link", data)
+	}
+	info, err := os.Lstat(cache_path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Mode().IsRegular() || info.Mode().Perm() != 0600 {
+		t.Errorf("writeSuggestionCache() wrote a file with mode %v, want -rw-------", info.Mode())
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("writeSuggestionCache() left files behind: %v", entries)
+	}
+
+	if got := readSuggestionCache(cache_path); !slices.Equal(got["ls"].Values, []string{"a", "b"}) {
+		t.Errorf("readSuggestionCache() = %v, want %v", got, cache)
+	}
+}
+
+func TestSuggestionCacheOwner(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("giving a file to another user needs root")
+	}
+	cache_path := filepath.Join(t.TempDir(), "suggestions.json")
+	cache := map[string]suggestionCacheEntry{"ls": {Values: []string{"a"}, Time: time.Now()}}
+	if err := writeSuggestionCache(cache, cache_path); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chown(cache_path, 12345, 12345); err != nil {
+		t.Fatal(err)
+	}
+	if got := readSuggestionCache(cache_path); len(got) != 0 {
+		t.Errorf("readSuggestionCache() of another user's file = %v, want it empty", got)
+	}
+}
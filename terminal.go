@@ -0,0 +1,231 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+const (
+	KEY_RUNE = iota
+	KEY_CTRL
+	KEY_ENTER
+	KEY_TAB
+	KEY_BACKSPACE
+	KEY_ESCAPE
+	KEY_UP
+	KEY_DOWN
+	KEY_LEFT
+	KEY_RIGHT
+	KEY_HOME
+	KEY_END
+	KEY_PAGE_UP
+	KEY_PAGE_DOWN
+	KEY_DELETE
+)
+
+// Rune is the typed character for KEY_RUNE and the lower case letter for KEY_CTRL
+type Key struct {
+	Type int
+	Rune rune
+}
+
+type keyRead struct {
+	keys []Key
+	err  error
+}
+
+// Terminal wraps a tty in raw mode. Keys are only read from the tty when
+// asked for via NextEvent so that a suspended terminal (e.g. while the
+// user's editor is open) does not have its input stolen.
+type Terminal struct {
+	In       *os.File
+	Out      *os.File
+	state    *term.State
+	reads    chan keyRead
+	resizes  chan os.Signal
+	pending  bool
+	buffered []Key
+}
+
+func OpenTerminal(in *os.File, out *os.File) (*Terminal, error) {
+	if !term.IsTerminal(int(in.Fd())) {
+		return nil, fmt.Errorf("input is not a terminal")
+	}
+	state, err := term.MakeRaw(int(in.Fd()))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Terminal{
+		In:      in,
+		Out:     out,
+		state:   state,
+		reads:   make(chan keyRead),
+		resizes: NotifyResize(),
+	}, nil
+}
+
+func (t *Terminal) Size() (int, int) {
+	width, height, err := term.GetSize(int(t.Out.Fd()))
+	if err != nil {
+		width, height, err = term.GetSize(int(t.In.Fd()))
+		if err != nil {
+			return 80, 24
+		}
+	}
+	return width, height
+}
+
+func (t *Terminal) Write(str string) {
+	t.Out.WriteString(str)
+}
+
+// Suspend hands the tty back in cooked mode, e.g. for running the editor
+func (t *Terminal) Suspend() {
+	term.Restore(int(t.In.Fd()), t.state)
+}
+
+func (t *Terminal) Resume() {
+	state, err := term.MakeRaw(int(t.In.Fd()))
+	if err == nil {
+		t.state = state
+	}
+}
+
+func (t *Terminal) Close() {
+	StopResize(t.resizes)
+	term.Restore(int(t.In.Fd()), t.state)
+}
+
+// NextEvent blocks until either a key is pressed or the terminal is resized.
+// resized is true for the latter.
+func (t *Terminal) NextEvent() (key Key, resized bool, err error) {
+	if len(t.buffered) > 0 {
+		key = t.buffered[0]
+		t.buffered = t.buffered[1:]
+		return key, false, nil
+	}
+
+	if !t.pending {
+		t.pending = true
+		go func() {
+			buf := make([]byte, 256)
+			n, err := t.In.Read(buf)
+			t.reads <- keyRead{keys: ParseKeys(buf[:n]), err: err}
+		}()
+	}
+
+	select {
+	case read := <-t.reads:
+		t.pending = false
+		if read.err != nil {
+			return key, false, read.err
+		}
+		if len(read.keys) == 0 {
+			return t.NextEvent()
+		}
+		t.buffered = read.keys[1:]
+		return read.keys[0], false, nil
+	case <-t.resizes:
+		return key, true, nil
+	}
+}
+
+var escapeSequences = map[string]int{
+	"[A":  KEY_UP,
+	"[B":  KEY_DOWN,
+	"[C":  KEY_RIGHT,
+	"[D":  KEY_LEFT,
+	"OA":  KEY_UP,
+	"OB":  KEY_DOWN,
+	"OC":  KEY_RIGHT,
+	"OD":  KEY_LEFT,
+	"[H":  KEY_HOME,
+	"[F":  KEY_END,
+	"OH":  KEY_HOME,
+	"OF":  KEY_END,
+	"[1~": KEY_HOME,
+	"[4~": KEY_END,
+	"[7~": KEY_HOME,
+	"[8~": KEY_END,
+	"[3~": KEY_DELETE,
+	"[5~": KEY_PAGE_UP,
+	"[6~": KEY_PAGE_DOWN,
+}
+
+func ParseKeys(buf []byte) []Key {
+	keys := []Key{}
+	for i := 0; i < len(buf); {
+		b := buf[i]
+		switch {
+		case b == 0x1b:
+			// Longest escape sequence is 3 bytes after ESC
+			matched := false
+			for length := 3; length >= 2; length-- {
+				if i+1+length > len(buf) {
+					continue
+				}
+				if key_type, ok := escapeSequences[string(buf[i+1:i+1+length])]; ok {
+					keys = append(keys, Key{Type: key_type})
+					i += 1 + length
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				keys = append(keys, Key{Type: KEY_ESCAPE})
+				i++
+				// Swallow the rest of an unknown CSI sequence
+				if i < len(buf) && buf[i] == '[' {
+					i++
+					for i < len(buf) && (buf[i] < 0x40 || buf[i] > 0x7e) {
+						i++
+					}
+					i++
+				}
+			}
+		case b == '\r' || b == '\n':
+			keys = append(keys, Key{Type: KEY_ENTER})
+			i++
+		case b == '\t':
+			keys = append(keys, Key{Type: KEY_TAB})
+			i++
+		case b == 0x7f || b == 0x08:
+			keys = append(keys, Key{Type: KEY_BACKSPACE})
+			i++
+		case b < 0x20:
+			keys = append(keys, Key{Type: KEY_CTRL, Rune: rune('a' + b - 1)})
+			i++
+		default:
+			r, size := utf8.DecodeRune(buf[i:])
+			keys = append(keys, Key{Type: KEY_RUNE, Rune: r})
+			i += size
+		}
+	}
+	return keys
+}
+
+/********************
+ * Escape sequences *
+ ********************/
+
+const (
+	ESC_ALT_SCREEN_ON  = "\x1b[?1049h"
+	ESC_ALT_SCREEN_OFF = "\x1b[?1049l"
+	ESC_CURSOR_HIDE    = "\x1b[?25l"
+	ESC_CURSOR_SHOW    = "\x1b[?25h"
+	ESC_CLEAR_SCREEN   = "\x1b[2J"
+	ESC_CLEAR_LINE     = "\x1b[K"
+	ESC_CLEAR_DOWN     = "\x1b[J"
+	ESC_RESET          = "\x1b[0m"
+	ESC_BOLD           = "\x1b[1m"
+	ESC_DIM            = "\x1b[2m"
+	ESC_REVERSE        = "\x1b[7m"
+)
+
+func MoveCursor(row int, col int) string {
+	return fmt.Sprintf("\x1b[%d;%dH", row+1, col+1)
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []Key
+	}{
+		{"", []Key{}},
+		{"aé日", []Key{{KEY_RUNE, 'a'}, {KEY_RUNE, 'é'}, {KEY_RUNE, '日'}}},
+		{"\r\n\t", []Key{{Type: KEY_ENTER}, {Type: KEY_ENTER}, {Type: KEY_TAB}}},
+		{"\x7f\x08", []Key{{Type: KEY_BACKSPACE}, {Type: KEY_BACKSPACE}}},
+		{"\x03\x19", []Key{{KEY_CTRL, 'c'}, {KEY_CTRL, 'y'}}},
+		// Both the CSI and SS3 forms of the arrows
+		{"\x1b[A\x1bOB\x1b[C\x1b[D", []Key{{Type: KEY_UP}, {Type: KEY_DOWN}, {Type: KEY_RIGHT}, {Type: KEY_LEFT}}},
+		{"\x1b[H\x1bOF\x1b[1~\x1b[4~", []Key{{Type: KEY_HOME}, {Type: KEY_END}, {Type: KEY_HOME}, {Type: KEY_END}}},
+		{"\x1b[3~x", []Key{{Type: KEY_DELETE}, {KEY_RUNE, 'x'}}},
+		{"\x1b[5~\x1b[6~", []Key{{Type: KEY_PAGE_UP}, {Type: KEY_PAGE_DOWN}}},
+		{"\x1b", []Key{{Type: KEY_ESCAPE}}},
+		{"\x1bx", []Key{{Type: KEY_ESCAPE}, {KEY_RUNE, 'x'}}},
+		// An unknown sequence, such as Ctrl+Right, is swallowed whole
+		{"\x1b[1;5Cx", []Key{{Type: KEY_ESCAPE}, {KEY_RUNE, 'x'}}},
+	}
+	for _, test := range tests {
+		if got := ParseKeys([]byte(test.input)); !slices.Equal(got, test.want) {
+			t.Errorf("ParseKeys(%q) = %v, want %v", test.input, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func NotifyResize() chan os.Signal {
+	resizes := make(chan os.Signal, 1)
+	signal.Notify(resizes, syscall.SIGWINCH)
+	return resizes
+}
+
+func StopResize(resizes chan os.Signal) {
+	signal.Stop(resizes)
+}
+
+// Opens the controlling terminal so interactive output stays off stdout
+func OpenTty() (*os.File, *os.File, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tty, tty, nil
+}
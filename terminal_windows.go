@@ -0,0 +1,28 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+)
+
+// Windows has no SIGWINCH; the screen is redrawn on the next key press instead
+func NotifyResize() chan os.Signal {
+	return nil
+}
+
+func StopResize(resizes chan os.Signal) {}
+
+// Opens the console so interactive output stays off stdout
+func OpenTty() (*os.File, *os.File, error) {
+	in, err := os.OpenFile("CONIN$", os.O_RDWR, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	out, err := os.OpenFile("CONOUT$", os.O_RDWR, 0)
+	if err != nil {
+		in.Close()
+		return nil, nil, err
+	}
+	return in, out, nil
+}
@@ -0,0 +1,530 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	TUI_MODE_BROWSE = iota
+	TUI_MODE_TAG
+	TUI_MODE_DELETE
+)
+
+const (
+	TUI_HELP         = "↑/↓ move  ←/→ tag filter  ^E edit  ^T tag  ^D delete  ^Y copy  ^G grouped  Esc quit"
+	TUI_HELP_GROUPED = "↑/↓ scroll  ←/→ tag filter  ^G list  Esc quit"
+)
+
+type Tui struct {
+	ui       *Ui
+	config   *Config
+	terminal *Terminal
+	memos    map[HASH]*Memo
+	hashes   []HASH // memos matching the current filter, in display order
+	tags     []string
+	tag      int // index into tags, 0 is all tags
+	query    string
+	cursor   int
+	offset   int
+	mode     int
+	grouped  bool
+	scroll   int // first line shown of the grouped view
+	input    string
+	status   string
+	width    int
+	height   int
+}
+
+func BrowseMemos(ui *Ui, config *Config) {
+	if non_interactive {
+		dataError("Cannot open the interactive browser when not interactive", EXIT_NO_INPUT)
+	}
+	terminal, err := OpenTerminal(os.Stdin, os.Stdout)
+	if err != nil {
+		dataError(fmt.Sprintf("Cannot open interactive browser: %v", err), EXIT_NO_INPUT)
+	}
+
+	tui := &Tui{
+		ui:       ui,
+		config:   config,
+		terminal: terminal,
+	}
+	tui.Reload()
+
+	terminal.Write(ESC_ALT_SCREEN_ON + ESC_CURSOR_HIDE)
+	defer func() {
+		terminal.Write(ESC_RESET + ESC_CURSOR_SHOW + ESC_ALT_SCREEN_OFF)
+		terminal.Close()
+	}()
+
+	tui.Run()
+}
+
+func (tui *Tui) Reload() {
+	tui.memos = LoadAllMemos(tui.config)
+
+	tags := make(map[string]bool)
+	for _, memo := range tui.memos {
+		for _, tag := range memo.Tags {
+			tags[tag] = true
+		}
+	}
+	selected_tag := ""
+	if tui.tag > 0 && tui.tag < len(tui.tags) {
+		selected_tag = tui.tags[tui.tag]
+	}
+	tui.tags = []string{}
+	for tag := range tags {
+		tui.tags = append(tui.tags, tag)
+	}
+	sort.Strings(tui.tags)
+	tui.tags = append([]string{"(all)"}, tui.tags...)
+	tui.tag = 0
+	for i, tag := range tui.tags {
+		if i > 0 && tag == selected_tag {
+			tui.tag = i
+		}
+	}
+
+	tui.Filter()
+}
+
+func (tui *Tui) Filter() {
+	terms := strings.Fields(tui.query)
+	tui.hashes = []HASH{}
+	for hash, memo := range tui.memos {
+		if tui.tag > 0 && !StringInSlice(tui.tags[tui.tag], memo.Tags) {
+			continue
+		}
+		matches := true
+		for _, search_term := range terms {
+			if !MemoMatchesSearch(search_term, memo, false, false) &&
+				!AnyIntersection([]string{search_term}, memo.Tags) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			tui.hashes = append(tui.hashes, hash)
+		}
+	}
+	sort.Slice(tui.hashes, func(i, j int) bool {
+		a := strings.ToLower(tui.memos[tui.hashes[i]].Title)
+		b := strings.ToLower(tui.memos[tui.hashes[j]].Title)
+		if a == b {
+			return tui.hashes[i] < tui.hashes[j]
+		}
+		return a < b
+	})
+
+	if tui.cursor >= len(tui.hashes) {
+		tui.cursor = len(tui.hashes) - 1
+	}
+	if tui.cursor < 0 {
+		tui.cursor = 0
+	}
+}
+
+func (tui *Tui) Selected() (HASH, *Memo) {
+	if len(tui.hashes) == 0 {
+		return "", nil
+	}
+	hash := tui.hashes[tui.cursor]
+	return hash, tui.memos[hash]
+}
+
+func (tui *Tui) Run() {
+	for {
+		tui.Draw()
+		key, resized, err := tui.terminal.NextEvent()
+		if err != nil {
+			return
+		}
+		if resized {
+			continue
+		}
+
+		switch tui.mode {
+		case TUI_MODE_TAG:
+			tui.HandleTagInput(key)
+		case TUI_MODE_DELETE:
+			tui.HandleDeleteConfirm(key)
+		default:
+			if !tui.HandleBrowse(key) {
+				return
+			}
+		}
+	}
+}
+
+// Returns false when the user quits
+func (tui *Tui) HandleBrowse(key Key) bool {
+	tui.status = ""
+	list_height := tui.height - 3
+	if tui.grouped {
+		switch key.Type {
+		case KEY_UP:
+			tui.scroll = max(0, tui.scroll-1)
+			return true
+		case KEY_DOWN:
+			tui.scroll++
+			return true
+		case KEY_PAGE_UP:
+			tui.scroll = max(0, tui.scroll-list_height)
+			return true
+		case KEY_PAGE_DOWN:
+			tui.scroll += list_height
+			return true
+		case KEY_CTRL:
+			if key.Rune != 'c' && key.Rune != 'q' && key.Rune != 'g' && key.Rune != 'u' {
+				return true
+			}
+		}
+	}
+	switch key.Type {
+	case KEY_ESCAPE:
+		return false
+	case KEY_CTRL:
+		switch key.Rune {
+		case 'c', 'q':
+			return false
+		case 'e':
+			tui.EditSelected()
+		case 't':
+			if _, memo := tui.Selected(); memo != nil {
+				tui.mode = TUI_MODE_TAG
+				tui.input = ""
+			}
+		case 'd':
+			if _, memo := tui.Selected(); memo != nil {
+				tui.mode = TUI_MODE_DELETE
+			}
+		case 'y':
+			tui.CopySelected()
+		case 'g':
+			tui.grouped = !tui.grouped
+			tui.scroll = 0
+		case 'u':
+			tui.query = ""
+			tui.Filter()
+		case 'n':
+			tui.MoveCursor(1)
+		case 'p':
+			tui.MoveCursor(-1)
+		}
+	case KEY_UP:
+		tui.MoveCursor(-1)
+	case KEY_DOWN:
+		tui.MoveCursor(1)
+	case KEY_PAGE_UP:
+		tui.MoveCursor(-list_height)
+	case KEY_PAGE_DOWN:
+		tui.MoveCursor(list_height)
+	case KEY_HOME:
+		tui.MoveCursor(-len(tui.hashes))
+	case KEY_END:
+		tui.MoveCursor(len(tui.hashes))
+	case KEY_LEFT:
+		tui.tag = (tui.tag + len(tui.tags) - 1) % len(tui.tags)
+		tui.Filter()
+	case KEY_RIGHT, KEY_TAB:
+		tui.tag = (tui.tag + 1) % len(tui.tags)
+		tui.Filter()
+	case KEY_BACKSPACE:
+		if len(tui.query) > 0 {
+			runes := []rune(tui.query)
+			tui.query = string(runes[0 : len(runes)-1])
+			tui.Filter()
+		}
+	case KEY_RUNE:
+		tui.query += string(key.Rune)
+		tui.cursor = 0
+		tui.Filter()
+	}
+	return true
+}
+
+func (tui *Tui) HandleTagInput(key Key) {
+	switch key.Type {
+	case KEY_ESCAPE:
+		tui.mode = TUI_MODE_BROWSE
+	case KEY_CTRL:
+		if key.Rune == 'c' {
+			tui.mode = TUI_MODE_BROWSE
+		}
+	case KEY_BACKSPACE:
+		if len(tui.input) > 0 {
+			runes := []rune(tui.input)
+			tui.input = string(runes[0 : len(runes)-1])
+		}
+	case KEY_RUNE:
+		tui.input += string(key.Rune)
+	case KEY_ENTER:
+		tui.mode = TUI_MODE_BROWSE
+		tag := strings.TrimSpace(tui.input)
+		_, memo := tui.Selected()
+		if tag == "" || memo == nil {
+			return
+		}
+		if strings.HasPrefix(tag, "-") {
+			tag = strings.TrimPrefix(tag, "-")
+			tags := []string{}
+			for _, found_tag := range memo.Tags {
+				if found_tag != tag {
+					tags = append(tags, found_tag)
+				}
+			}
+			memo.Tags = tags
+			tui.status = fmt.Sprintf("Removed tag '%s'", tag)
+		} else if !StringInSlice(tag, memo.Tags) {
+			memo.Tags = append(memo.Tags, tag)
+			tui.status = fmt.Sprintf("Added tag '%s'", tag)
+		}
+		if _, err := memo.Save(); err != nil {
+			tui.status = fmt.Sprintf("Could not save '%s': %v", memo.Title, err)
+		}
+		tui.Reload()
+	}
+}
+
+func (tui *Tui) HandleDeleteConfirm(key Key) {
+	tui.mode = TUI_MODE_BROWSE
+	_, memo := tui.Selected()
+	if memo == nil || key.Type != KEY_RUNE || (key.Rune != 'y' && key.Rune != 'Y') {
+		tui.status = "Delete cancelled"
+		return
+	}
+	if err := memo.Delete(); err != nil {
+		tui.status = fmt.Sprintf("Could not delete '%s': %v", memo.Title, err)
+		return
+	}
+	tui.status = fmt.Sprintf("Deleted '%s'", memo.Title)
+	tui.Reload()
+}
+
+func (tui *Tui) MoveCursor(delta int) {
+	tui.cursor += delta
+	if tui.cursor >= len(tui.hashes) {
+		tui.cursor = len(tui.hashes) - 1
+	}
+	if tui.cursor < 0 {
+		tui.cursor = 0
+	}
+}
+
+func (tui *Tui) EditSelected() {
+	_, memo := tui.Selected()
+	if memo == nil {
+		return
+	}
+
+	tui.terminal.Write(ESC_CURSOR_SHOW + ESC_ALT_SCREEN_OFF)
+	tui.terminal.Suspend()
+	new_content, err := tui.ui.EditContent(tui.config, memo.Content, TypeExtension(memo.Type))
+	tui.terminal.Resume()
+	tui.terminal.Write(ESC_ALT_SCREEN_ON + ESC_CURSOR_HIDE)
+
+	if err != nil {
+		tui.status = fmt.Sprintf("Not saved: %v", err)
+		return
+	}
+	memo.Content = new_content
+	if _, err := memo.Save(); err != nil {
+		tui.status = fmt.Sprintf("Could not save '%s': %v", memo.Title, err)
+	} else {
+		tui.status = fmt.Sprintf("Saved '%s'", memo.Title)
+	}
+	tui.Reload()
+}
+
+func (tui *Tui) CopySelected() {
+	_, memo := tui.Selected()
+	if memo == nil {
+		return
+	}
+	if err := CopyToClipboard(tui.config, memo.Content); err != nil {
+		tui.status = fmt.Sprintf("Could not copy: %v", err)
+		return
+	}
+	tui.status = fmt.Sprintf("Copied '%s'", memo.Title)
+}
+
+/***********
+ * Drawing *
+ ***********/
+
+func (tui *Tui) Draw() {
+	tui.width, tui.height = tui.terminal.Size()
+	width := tui.width
+	height := tui.height
+	body_height := height - 2
+	if width < 20 || body_height < 3 {
+		tui.terminal.Write(ESC_CLEAR_SCREEN + MoveCursor(0, 0) + "Terminal too small")
+		return
+	}
+
+	sidebar_width := 0
+	if width >= 60 {
+		sidebar_width = min(18, width/5)
+	}
+	list_width := (width - sidebar_width) * 2 / 5
+	preview_width := width - sidebar_width - list_width - 2
+	if sidebar_width > 0 {
+		preview_width--
+	}
+
+	// Keep the cursor on screen
+	list_height := body_height - 1
+	if tui.cursor < tui.offset {
+		tui.offset = tui.cursor
+	} else if tui.cursor >= tui.offset+list_height {
+		tui.offset = tui.cursor - list_height + 1
+	}
+
+	sidebar := tui.DrawSidebar(sidebar_width, body_height)
+	var list, preview, grouped []string
+	if tui.grouped {
+		grouped = tui.DrawGrouped(width-sidebar_width-2, body_height)
+	} else {
+		list = tui.DrawList(list_width, body_height)
+		preview = tui.DrawPreview(preview_width, body_height)
+	}
+
+	var screen strings.Builder
+	screen.WriteString(MoveCursor(0, 0))
+	header := fmt.Sprintf(" %s  filter: %s", APP_NAME, tui.query)
+	if tui.mode == TUI_MODE_BROWSE {
+		header += "_"
+	}
+	counts := fmt.Sprintf("%d/%d ", len(tui.hashes), len(tui.memos))
+	screen.WriteString(ESC_REVERSE + PadRight(header, width-len(counts)) + counts + ESC_RESET)
+
+	for row := 0; row < body_height; row++ {
+		screen.WriteString(MoveCursor(row+1, 0))
+		if sidebar_width > 0 {
+			screen.WriteString(sidebar[row])
+			screen.WriteString(ESC_DIM + "│" + ESC_RESET)
+		}
+		if tui.grouped {
+			screen.WriteString(" " + grouped[row])
+		} else {
+			screen.WriteString(list[row])
+			screen.WriteString(ESC_DIM + "│" + ESC_RESET + " ")
+			screen.WriteString(preview[row])
+		}
+		screen.WriteString(ESC_CLEAR_LINE)
+	}
+
+	screen.WriteString(MoveCursor(height-1, 0))
+	screen.WriteString(PadRight(tui.StatusLine(), width))
+	tui.terminal.Write(screen.String())
+}
+
+func (tui *Tui) StatusLine() string {
+	switch tui.mode {
+	case TUI_MODE_TAG:
+		return "Tag to add (prefix with - to remove): " + tui.input + "_"
+	case TUI_MODE_DELETE:
+		_, memo := tui.Selected()
+		return fmt.Sprintf("Delete '%s'? (y/n)", memo.Title)
+	}
+	if tui.status != "" {
+		return tui.status
+	}
+	if tui.grouped {
+		return TUI_HELP_GROUPED
+	}
+	return TUI_HELP
+}
+
+func (tui *Tui) DrawSidebar(width int, height int) []string {
+	lines := make([]string, height)
+	lines[0] = ESC_BOLD + PadRight(" TAGS", width) + ESC_RESET
+	offset := 0
+	if tui.tag >= height-1 {
+		offset = tui.tag - height + 2
+	}
+	for row := 1; row < height; row++ {
+		i := row - 1 + offset
+		if i >= len(tui.tags) {
+			lines[row] = strings.Repeat(" ", width)
+		} else if i == tui.tag {
+			lines[row] = ESC_REVERSE + PadRight(" "+tui.tags[i], width) + ESC_RESET
+		} else {
+			lines[row] = PadRight(" "+tui.tags[i], width)
+		}
+	}
+	return lines
+}
+
+func (tui *Tui) DrawList(width int, height int) []string {
+	lines := make([]string, height)
+	lines[0] = ESC_BOLD + PadRight(" HASH      TITLE", width) + ESC_RESET
+	for row := 1; row < height; row++ {
+		i := row - 1 + tui.offset
+		if i >= len(tui.hashes) {
+			lines[row] = strings.Repeat(" ", width)
+			continue
+		}
+		hash := tui.hashes[i]
+		line := PadRight(fmt.Sprintf(" %s  %s", hash[0:8], tui.memos[hash].Title), width)
+		if i == tui.cursor {
+			line = ESC_REVERSE + line + ESC_RESET
+		}
+		lines[row] = line
+	}
+	return lines
+}
+
+func (tui *Tui) DrawPreview(width int, height int) []string {
+	lines := make([]string, height)
+	for row := range lines {
+		lines[row] = ""
+	}
+	_, memo := tui.Selected()
+	if memo == nil {
+		lines[0] = "No memos"
+		return lines
+	}
+
+	preview := []string{ESC_BOLD + Truncate(memo.Title, width) + ESC_RESET}
+	if len(memo.Tags) > 0 {
+		preview = append(preview, ESC_DIM+Truncate("tags: "+strings.Join(memo.Tags, ", "), width)+ESC_RESET)
+	}
+	if memo.Type != "" {
+		preview = append(preview, ESC_DIM+Truncate("type: "+memo.Type, width)+ESC_RESET)
+	}
+	preview = append(preview, "")
+	for _, chunk := range Chunks(memo.Content, width) {
+		line := Truncate(chunk, width)
+		if UseColor() {
+			line = Highlight(memo.Type, line)
+		}
+		preview = append(preview, line)
+	}
+
+	for row := 0; row < height && row < len(preview); row++ {
+		lines[row] = preview[row]
+	}
+	return lines
+}
+
+func (tui *Tui) DrawGrouped(width int, height int) []string {
+	memos := make(map[HASH]*Memo)
+	for _, hash := range tui.hashes {
+		memos[hash] = tui.memos[hash]
+	}
+	grouped := GroupedLines(memos, width)
+	tui.scroll = max(0, min(tui.scroll, len(grouped)-height))
+
+	lines := make([]string, height)
+	for row := range lines {
+		if tui.scroll+row < len(grouped) {
+			lines[row] = grouped[tui.scroll+row]
+		}
+	}
+	return lines
+}
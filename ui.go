@@ -2,8 +2,9 @@ package main
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
-	"log"
+	"io"
 	"math"
 	"os"
 	"os/exec"
@@ -16,31 +17,161 @@ import (
 )
 
 type Ui struct {
-	Scanner *bufio.Scanner
+	Scanner     *bufio.Scanner
+	Out         io.Writer // where prompts are written
+	Interactive bool      // whether Scanner reads from a terminal
 }
 
 func CreateUi() *Ui {
 	return &Ui{
 		Scanner: bufio.NewScanner(os.Stdin),
+		// Prompts stay out of output that may be piped
+		Out:         os.Stderr,
+		Interactive: !StdinIsPiped(),
 	}
 }
 
+// The Ui on the terminal, opened by the first OnTty and kept open until exit
+var tty_ui *Ui
+
+// A Ui that prompts on the terminal itself, keeping stdout free for output.
+// Falls back to ui when there is no terminal or with --non-interactive.
+// The terminal is only opened once, and shared by every caller.
+func (ui *Ui) OnTty() *Ui {
+	if non_interactive {
+		return ui
+	}
+	if tty_ui == nil {
+		in, out, err := OpenTty()
+		if err != nil {
+			return ui
+		}
+		tty_ui = &Ui{
+			Scanner:     bufio.NewScanner(in),
+			Out:         out,
+			Interactive: true,
+		}
+	}
+	return tty_ui
+}
+
+// Whether the user can be asked for input, which needs a terminal and no
+// --non-interactive
+func (ui *Ui) CanPrompt() bool {
+	return ui.Interactive && !non_interactive
+}
+
+// Exits with EXIT_NO_INPUT unless the user can be asked question. hint
+// says how to give the answer up front, e.g. "Use (-y/--yes) to confirm."
+func (ui *Ui) RequirePrompt(question string, hint string) {
+	if ui.CanPrompt() {
+		return
+	}
+	message := fmt.Sprintf("Cannot ask '%s' when not interactive.", Question(question))
+	if hint != "" {
+		message += " " + hint
+	}
+	dataError(message, EXIT_NO_INPUT)
+}
+
 /*********
  * Input *
  *********/
 
+var ErrEditAborted = errors.New("edit aborted")
+var ErrNotInteractive = errors.New("cannot open an editor when not interactive")
+
+// EXIT_ABORTED when the edit was given up on, EXIT_NO_INPUT when the editor
+// couldn't be opened for it, otherwise EXIT_ERROR
+func EditExitStatus(err error) int {
+	if errors.Is(err, ErrEditAborted) {
+		return EXIT_ABORTED
+	} else if errors.Is(err, ErrNotInteractive) {
+		return EXIT_NO_INPUT
+	}
+	return EXIT_ERROR
+}
+
+// Editors tried in order when none is configured
+var fallbackEditors = []string{"vi", "nano"}
+
+// The editor command, split into words: `Editor` from the config, then
+// $VISUAL, $EDITOR, `DefaultEditor` from the config, then vi or nano
+func GetEditor(config *Config) ([]string, error) {
+	for _, setting := range []string{
+		config.Editor,
+		os.Getenv("VISUAL"),
+		os.Getenv("EDITOR"),
+		config.DefaultEditor,
+	} {
+		if strings.TrimSpace(setting) == "" {
+			continue
+		}
+		editor, err := ShellWords(setting)
+		if err != nil {
+			return nil, fmt.Errorf("could not read editor '%s': %v", setting, err)
+		}
+		if _, err := exec.LookPath(editor[0]); err != nil {
+			return nil, fmt.Errorf("editor '%s' not found", editor[0])
+		}
+		return editor, nil
+	}
+	for _, editor := range fallbackEditors {
+		if _, err := exec.LookPath(editor); err == nil {
+			return []string{editor}, nil
+		}
+	}
+	return nil, errors.New("no editor found. Set $EDITOR or `Editor` in the config")
+}
+
+// The content argument that means "read from stdin"
+const STDIN_ARG = "-"
+
+// Whether stdin is piped or redirected rather than a terminal
+func StdinIsPiped() bool {
+	return !term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// Reads all of stdin, without the trailing newline most commands end with
+func ReadStdin() string {
+	bytes, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		dataError(fmt.Sprintf("Could not read stdin: %v", err))
+	}
+	return strings.TrimRight(string(bytes), "\r\n")
+}
+
+// The last line of a prompt, e.g. "Edit? (y/n)", for messages about it
+func Question(prompt string) string {
+	lines := strings.Split(strings.TrimSpace(prompt), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// The next line of input, trimmed. Exits with EXIT_NO_INPUT when the user
+// can't be asked or the input ends before an answer to prompt.
+func (ui *Ui) ReadLine(prompt string) string {
+	ui.RequirePrompt(prompt, "")
+	if !ui.Scanner.Scan() {
+		fmt.Fprintln(ui.Out)
+		if err := ui.Scanner.Err(); err != nil {
+			dataError(fmt.Sprintf("Could not read an answer to '%s': %v", Question(prompt), err), EXIT_NO_INPUT)
+		}
+		dataError(fmt.Sprintf("Input ended without an answer to '%s'", Question(prompt)), EXIT_NO_INPUT)
+	}
+	return strings.TrimSpace(ui.Scanner.Text())
+}
+
 func (ui *Ui) GetResponse(
 	prompt string,
 	followUp string,
 	acceptableResponses []string,
 ) string {
-	fmt.Print(prompt)
+	fmt.Fprint(ui.Out, prompt)
 	var text string
 	for {
-		ui.Scanner.Scan()
-		text = strings.TrimSpace(ui.Scanner.Text())
+		text = ui.ReadLine(prompt)
 		if !slices.Contains(acceptableResponses, text) {
-			fmt.Print(followUp)
+			fmt.Fprint(ui.Out, followUp)
 		} else {
 			break
 		}
@@ -52,13 +183,12 @@ func (ui *Ui) GetText(
 	prompt string,
 	followUp string,
 ) string {
-	fmt.Print(prompt)
+	fmt.Fprint(ui.Out, prompt)
 	var text string
 	for {
-		ui.Scanner.Scan()
-		text = strings.TrimSpace(ui.Scanner.Text())
+		text = ui.ReadLine(prompt)
 		if len(text) == 0 && followUp != "" {
-			fmt.Print(followUp)
+			fmt.Fprint(ui.Out, followUp)
 		} else {
 			break
 		}
@@ -72,28 +202,30 @@ func (ui *Ui) GetInt(
 	min int,
 	max int, // inclusive
 ) int {
-	fmt.Print(prompt)
+	fmt.Fprint(ui.Out, prompt)
 	var text string
 	for {
-		ui.Scanner.Scan()
-		text = strings.TrimSpace(ui.Scanner.Text())
+		text = ui.ReadLine(prompt)
 		i, err := strconv.Atoi(text)
 		if err != nil || i < min || i > max {
-			fmt.Print(followUp)
+			fmt.Fprint(ui.Out, followUp)
 		} else {
 			return i
 		}
 	}
 }
 
+// Reads lines until doneText, or the end of input
 func (ui *Ui) GetMultilineText(prompt string, doneText string) string {
-	lineText := ""
+	ui.RequirePrompt(prompt, "")
 	totalText := ""
 	for {
-		lineText = ui.GetText(
-			prompt,
-			"",
-		)
+		fmt.Fprint(ui.Out, prompt)
+		if !ui.Scanner.Scan() {
+			fmt.Fprintln(ui.Out)
+			break
+		}
+		lineText := strings.TrimSpace(ui.Scanner.Text())
 		if lineText == doneText {
 			break
 		}
@@ -107,159 +239,151 @@ func (ui *Ui) GetMultilineText(prompt string, doneText string) string {
  * Memos *
  *********/
 
+// Opens content in the user's editor and returns what was saved. The file
+// gets extension so the editor picks the right syntax highlighting. Returns
+// ErrEditAborted, with the reason, if the editor fails or the buffer is left
+// empty or unchanged. Based on
 // https://github.com/msemjan/go-external-editor/blob/9e2e6ee617d8dcb9a41a86e49282170327ba524d/main.go#L22C2-L66C3
-func (ui *Ui) EditContent(fileContents string) string {
-	// Create a temporary file
-	f, err1 := os.CreateTemp("", "memo-*")
-	if err1 != nil {
-		log.Fatal(err1)
+func (ui *Ui) EditContent(config *Config, content string, extension string) (string, error) {
+	if !ui.CanPrompt() {
+		return "", ErrNotInteractive
+	}
+	editor, err := GetEditor(config)
+	if err != nil {
+		return "", err
 	}
-	defer f.Close()
-	defer os.Remove(f.Name())
 
-	// Fill the termporary file with some data
-	f.WriteString(fileContents)
+	pattern := "memo-*"
+	if extension != "" {
+		pattern += "." + extension
+	}
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(content)
+	f.Close()
+	if err != nil {
+		return "", err
+	}
 
-	// Open the temporary file in Vim for editing
-	editor := strings.TrimSpace(os.Getenv("EDITOR"))
-	cmd := exec.Command(editor, f.Name())
+	cmd := exec.Command(editor[0], append(editor[1:], f.Name())...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-
-	// Open the temporary file in an external editor
-	err2 := cmd.Start()
-	if err2 != nil {
-		log.Printf("2")
-		log.Fatal(err2)
-	}
-
-	// Wait for the user to do their thing...
-	err2 = cmd.Wait()
-
-	// Check if they didn't mess up everything
-	if err2 != nil {
-		log.Printf("Error while editing. Error: %v\n", err2)
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%w: '%s' failed: %v", ErrEditAborted, strings.Join(editor, " "), err)
 	}
 
-	content, err := os.ReadFile(f.Name())
+	edited, err := os.ReadFile(f.Name())
 	if err != nil {
-		fmt.Println("Err")
+		return "", err
 	}
-
-	return string(content)
+	if strings.TrimSpace(string(edited)) == "" {
+		return "", fmt.Errorf("%w: the buffer was empty", ErrEditAborted)
+	}
+	if string(edited) == content {
+		return "", fmt.Errorf("%w: nothing was changed", ErrEditAborted)
+	}
+	return string(edited), nil
 }
 
-func (ui *Ui) PrintMemos(memos map[string]*Memo, skip_formatting bool) {
+func (ui *Ui) PrintMemos(memos map[string]*Memo, options *PrintOptions) {
 	width := GetTermWidth()
+	hashes := SortHashes(memos, options.Sort, options.Reverse)
 
-	if width == 0 || skip_formatting {
-		for hash, memo := range memos {
-			ui.PrintMemo(hash, memo)
+	if width == 0 || options.SkipFormatting {
+		for _, hash := range hashes {
+			ui.PrintMemo(hash, memos[hash], options)
 			fmt.Println()
 		}
-	} else {
-		// Assuming tab width == 4 for now
-		// sha 8 + 4 space + title + 4 space + content
-		var max_title_length float64 = 0
-		var max_content_length float64 = 0
-		var max_tag_length float64 = 0
-		for _, memo := range memos {
-			max_title_length = math.Max(max_title_length, float64(len(memo.Title)))
-			max_content_length = math.Max(max_content_length, LongestOfMultiline(memo.Content))
-			for _, tag := range memo.Tags {
-				max_tag_length = math.Max(max_tag_length, float64(len(tag)))
-			}
-		}
-		title_memo := &Memo{
-			Title:   "TITLE",
-			Content: "CONTENT",
-			Tags:    []string{"TAGS"},
-		}
-		max_title_length = math.Max(max_title_length, float64(len(title_memo.Title)))
-		max_content_length = math.Max(max_content_length, LongestOfMultiline(title_memo.Content))
-		max_tag_length = math.Max(max_tag_length, float64(len(title_memo.Tags[0])))
-
-		title_length := int(math.Min(max_title_length, float64((width-20)/3)))
-		tag_width := int(max_tag_length)
-		content_length := int(math.Min(max_content_length, float64(width-20-title_length-tag_width-1))) // 1 for right side padding
-		// If there's extra room, expand tags
-		tag_width = width - content_length - 20 - title_length - 1
-		ui.PrintMemoFancy(
-			"HASH    ",
-			title_memo,
-			title_length,
-			content_length,
-			tag_width,
-		)
-		fmt.Println()
+		return
+	}
 
-		hashes := make([]string, 0)
-		for hash := range memos {
-			hashes = append(hashes, hash)
+	// Start every column at the width of its widest value, then take space
+	// from the widest flexible columns until the table fits
+	widths := make([]int, len(options.Columns))
+	for c, column := range options.Columns {
+		widths[c] = StringWidth(strings.ToUpper(column))
+		for hash, memo := range memos {
+			widths[c] = max(widths[c], ColumnNaturalWidth(column, hash, memo))
 		}
-
-		// Now sort the slice
-		sort.Strings(hashes)
-
-		// Iterate over all keys in a sorted order
-		for _, hash := range hashes {
-			memo := memos[hash]
-			ui.PrintMemoFancy(
-				hash,
-				memo,
-				title_length,
-				content_length,
-				tag_width,
-			)
-			fmt.Println()
+		if max_width, ok := options.ColumnWidths[column]; ok && max_width > 0 && COLUMN_FLEXIBLE[column] {
+			widths[c] = min(widths[c], max_width)
 		}
 	}
-}
-
-func (ui *Ui) PrintMemoFancy(hash string, memo *Memo, title_length int, content_length int, tag_length int) {
-	contents := Chunks(memo.Content, content_length)
-	titles := Chunks(memo.Title, title_length)
-	tags := Chunks(strings.Join(memo.Tags, ", "), tag_length)
-	lines := int(math.Max(float64(len(contents)), math.Max(float64(len(titles)), float64(len(tags)))))
-	for i := range lines {
-		if i == 0 {
-			fmt.Print(hash[0:8])
-		} else {
-			fmt.Print(strings.Repeat(" ", 8))
+	available := width - COLUMN_GAP*(len(widths)-1) - 1 // 1 for right side padding
+	for {
+		total := 0
+		widest := -1
+		for c, column_width := range widths {
+			total += column_width
+			if COLUMN_FLEXIBLE[options.Columns[c]] && column_width > COLUMN_MIN_WIDTH &&
+				(widest == -1 || column_width > widths[widest]) {
+				widest = c
+			}
 		}
-
-		fmt.Print(strings.Repeat(" ", 4))
-
-		if len(titles) > i {
-			fmt.Printf("%-*s", title_length, titles[i])
-		} else {
-			fmt.Print(strings.Repeat(" ", title_length))
+		if total <= available || widest == -1 {
+			break
 		}
+		widths[widest]--
+	}
 
-		fmt.Print(strings.Repeat(" ", 4))
-
-		if len(contents) > i {
-			fmt.Printf("%-*s", content_length, contents[i])
-		} else {
-			fmt.Print(strings.Repeat(" ", content_length))
+	header := make([][]string, len(options.Columns))
+	for c, column := range options.Columns {
+		header[c] = []string{strings.ToUpper(column)}
+	}
+	ui.PrintMemoFancy(header, widths)
+	fmt.Println()
+
+	for _, hash := range hashes {
+		memo := memos[hash]
+		cells := make([][]string, len(options.Columns))
+		for c, column := range options.Columns {
+			cells[c] = ColumnLines(column, hash, memo, widths[c], options)
 		}
+		ui.PrintMemoFancy(cells, widths)
+		fmt.Println()
+	}
+}
 
-		fmt.Print(strings.Repeat(" ", 4))
-
-		if len(tags) > i {
-			fmt.Printf("%-*s", tag_length, tags[i])
-		} else {
-			fmt.Print(strings.Repeat(" ", tag_length))
+// Prints one table row, each cell being the already wrapped lines of a column
+func (ui *Ui) PrintMemoFancy(cells [][]string, widths []int) {
+	lines := 0
+	for _, cell := range cells {
+		lines = max(lines, len(cell))
+	}
+	gap := strings.Repeat(" ", COLUMN_GAP)
+	for i := range lines {
+		row := ""
+		for c, cell := range cells {
+			if c > 0 {
+				row += gap
+			}
+			if len(cell) > i {
+				row += PadRight(cell[i], widths[c])
+			} else {
+				row += strings.Repeat(" ", widths[c])
+			}
 		}
-
-		fmt.Println()
+		fmt.Println(row)
 	}
 }
 
-func (ui *Ui) PrintMemo(hash string, memo *Memo) {
-	fmt.Printf("%s\t%s\t%s", hash[0:8], memo.Title, strings.ReplaceAll(memo.Content, "\n", "\\n"))
-	fmt.Printf("\t%s", strings.Join(memo.Tags, ", "))
+func (ui *Ui) PrintMemo(hash string, memo *Memo, options *PrintOptions) {
+	values := []string{}
+	for _, column := range options.Columns {
+		value := ColumnValue(column, hash, memo)
+		if column == COLUMN_CONTENT && options.ContentLines > 0 {
+			lines := strings.Split(value, "\n")
+			if len(lines) > options.ContentLines {
+				value = strings.Join(lines[0:options.ContentLines], "\n") + ELLIPSIS
+			}
+		}
+		values = append(values, strings.ReplaceAll(value, "\n", "\\n"))
+	}
+	fmt.Print(strings.Join(values, "\t"))
 }
 
 /************
@@ -267,6 +391,8 @@ func (ui *Ui) PrintMemo(hash string, memo *Memo) {
  ************/
 
 // https://stackoverflow.com/a/61469854
+// Widths are measured in terminal cells and words wider than chunkSize are
+// broken up between characters.
 func Chunks(str string, chunkSize int) []string {
 	if len(str) == 0 {
 		return nil
@@ -274,24 +400,41 @@ func Chunks(str string, chunkSize int) []string {
 
 	chunks := []string{}
 	currentChunk := ""
+	currentWidth := 0
 
 	lines := strings.Split(str, "\n")
 	for _, line := range lines {
 
-		strs := strings.Split(line, " ")
+		strs := strings.Split(ExpandTabs(line), " ")
 		for _, t := range strs {
+			width := StringWidth(t)
+			if width > chunkSize && width > 0 {
+				pieces := HardWrap(t, chunkSize)
+				if currentChunk != "" {
+					chunks = append(chunks, currentChunk)
+				}
+				chunks = append(chunks, pieces[0:len(pieces)-1]...)
+				t = pieces[len(pieces)-1]
+				width = StringWidth(t)
+				currentChunk = ""
+				currentWidth = 0
+			}
 
 			if currentChunk == "" {
 				currentChunk = t
-			} else if len(currentChunk)+1+len(t) > chunkSize {
+				currentWidth = width
+			} else if currentWidth+1+width > chunkSize {
 				chunks = append(chunks, currentChunk)
 				currentChunk = t
+				currentWidth = width
 			} else {
 				currentChunk += " " + t
+				currentWidth += 1 + width
 			}
 		}
 		chunks = append(chunks, currentChunk)
 		currentChunk = ""
+		currentWidth = 0
 	}
 
 	return chunks
@@ -314,8 +457,168 @@ func LongestOfMultiline(str string) float64 {
 	lines := strings.Split(str, "\n")
 	var max_len float64 = 0
 	for _, line := range lines {
-		max_len = math.Max(max_len, float64(len(line)))
+		max_len = math.Max(max_len, float64(StringWidth(ExpandTabs(line))))
 	}
 
 	return max_len
 }
+
+// Truncates str to at most width terminal cells. ANSI escape sequences are
+// kept so that styling is still reset at the end.
+func Truncate(str string, width int) string {
+	if StringWidth(str) <= width {
+		return str
+	}
+	var truncated strings.Builder
+	used := 0
+	for _, cluster := range Graphemes(str) {
+		cluster_width := GraphemeWidth(cluster)
+		if cluster_width == 0 && escapeLength(cluster) > 0 {
+			truncated.WriteString(cluster)
+		} else if used+cluster_width <= width {
+			truncated.WriteString(cluster)
+			used += cluster_width
+		} else {
+			// Nothing after this point fits, but keep checking for escapes
+			used = width + 1
+		}
+	}
+	return truncated.String()
+}
+
+// Truncates or pads str with spaces to exactly width terminal cells
+func PadRight(str string, width int) string {
+	str = Truncate(str, width)
+	return str + strings.Repeat(" ", max(0, width-StringWidth(str)))
+}
+
+/***********
+ * Grouped *
+ ***********/
+
+const (
+	GROUPED_MIN_COLUMN_WIDTH = 40
+	GROUPED_COLUMN_GAP       = 4
+	GROUPED_UNTAGGED         = "untagged"
+)
+
+type MemoGroup struct {
+	Tag    string
+	Hashes []HASH
+}
+
+// Groups memos by tag, memos with several tags appear in each group
+func GroupMemos(memos map[HASH]*Memo) []MemoGroup {
+	by_tag := make(map[string][]HASH)
+	for hash, memo := range memos {
+		if len(memo.Tags) == 0 {
+			by_tag[GROUPED_UNTAGGED] = append(by_tag[GROUPED_UNTAGGED], hash)
+		}
+		for _, tag := range memo.Tags {
+			by_tag[tag] = append(by_tag[tag], hash)
+		}
+	}
+
+	tags := []string{}
+	for tag := range by_tag {
+		if tag != GROUPED_UNTAGGED {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	if _, ok := by_tag[GROUPED_UNTAGGED]; ok {
+		tags = append(tags, GROUPED_UNTAGGED)
+	}
+
+	groups := []MemoGroup{}
+	for _, tag := range tags {
+		hashes := by_tag[tag]
+		sort.Slice(hashes, func(i, j int) bool {
+			return strings.ToLower(memos[hashes[i]].Title) < strings.ToLower(memos[hashes[j]].Title)
+		})
+		groups = append(groups, MemoGroup{Tag: tag, Hashes: hashes})
+	}
+	return groups
+}
+
+func FirstLine(str string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(str), "\n")
+	return strings.TrimSpace(line)
+}
+
+// Renders memos as blocks of `title → content` rows under a heading per tag,
+// packed into as many columns as fit in width
+func GroupedLines(memos map[HASH]*Memo, width int) []string {
+	columns := max(1, (width+GROUPED_COLUMN_GAP)/(GROUPED_MIN_COLUMN_WIDTH+GROUPED_COLUMN_GAP))
+	column_width := (width - GROUPED_COLUMN_GAP*(columns-1)) / columns
+
+	blocks := [][]string{}
+	total_lines := 0
+	for _, group := range GroupMemos(memos) {
+		title_width := 0
+		for _, hash := range group.Hashes {
+			title_width = max(title_width, StringWidth(memos[hash].Title))
+		}
+		title_width = min(title_width, column_width/2)
+
+		block := []string{ESC_BOLD + PadRight(group.Tag, column_width) + ESC_RESET}
+		for _, hash := range group.Hashes {
+			memo := memos[hash]
+			row := PadRight(memo.Title, title_width) + " → " + FirstLine(memo.Content)
+			block = append(block, PadRight(row, column_width))
+		}
+		blocks = append(blocks, block)
+		total_lines += len(block) + 1
+	}
+
+	// Fill columns in order, moving on once a column reaches its share
+	target_height := (total_lines + columns - 1) / columns
+	packed := make([][]string, columns)
+	column := 0
+	for _, block := range blocks {
+		if column < columns-1 && len(packed[column]) > 0 && len(packed[column])+len(block) > target_height {
+			column++
+		}
+		if len(packed[column]) > 0 {
+			packed[column] = append(packed[column], strings.Repeat(" ", column_width))
+		}
+		packed[column] = append(packed[column], block...)
+	}
+
+	height := 0
+	for _, lines := range packed {
+		height = max(height, len(lines))
+	}
+	lines := make([]string, height)
+	gap := strings.Repeat(" ", GROUPED_COLUMN_GAP)
+	for row := range height {
+		cells := []string{}
+		for _, column_lines := range packed {
+			if row < len(column_lines) {
+				cells = append(cells, column_lines[row])
+			} else {
+				cells = append(cells, strings.Repeat(" ", column_width))
+			}
+		}
+		lines[row] = strings.TrimRight(strings.Join(cells, gap), " ")
+	}
+	return lines
+}
+
+func (ui *Ui) PrintMemosGrouped(memos map[HASH]*Memo, skip_formatting bool) {
+	width := GetTermWidth()
+
+	if width == 0 || skip_formatting {
+		for _, group := range GroupMemos(memos) {
+			for _, hash := range group.Hashes {
+				memo := memos[hash]
+				fmt.Printf("%s\t%s\t%s\t%s\n", group.Tag, hash[0:8], memo.Title, FirstLine(memo.Content))
+			}
+		}
+		return
+	}
+
+	for _, line := range GroupedLines(memos, width) {
+		fmt.Println(line)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestChunks(t *testing.T) {
+	tests := []struct {
+		str   string
+		width int
+		want  []string
+	}{
+		{"", 5, nil},
+		{"hello world", 5, []string{"hello", "world"}},
+		{"a b c", 3, []string{"a b", "c"}},
+		{"abcdefgh", 3, []string{"abc", "def", "gh"}},
+		{"one\ntwo", 10, []string{"one", "two"}},
+		{"日本語 x", 4, []string{"日本", "語 x"}},
+	}
+	for _, test := range tests {
+		if got := Chunks(test.str, test.width); !slices.Equal(got, test.want) {
+			t.Errorf("Chunks(%q, %d) = %q, want %q", test.str, test.width, got, test.want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		str   string
+		width int
+		want  string
+	}{
+		{"hi", 5, "hi"},
+		{"hello", 3, "hel"},
+		{"日本語", 5, "日本"},
+		{"\x1b[31mhello\x1b[0m", 2, "\x1b[31mhe\x1b[0m"},
+	}
+	for _, test := range tests {
+		if got := Truncate(test.str, test.width); got != test.want {
+			t.Errorf("Truncate(%q, %d) = %q, want %q", test.str, test.width, got, test.want)
+		}
+	}
+}
+
+func TestPadRight(t *testing.T) {
+	tests := []struct {
+		str   string
+		width int
+		want  string
+	}{
+		{"ab", 4, "ab  "},
+		{"日", 4, "日  "},
+		{"abcdef", 4, "abcd"},
+	}
+	for _, test := range tests {
+		if got := PadRight(test.str, test.width); got != test.want {
+			t.Errorf("PadRight(%q, %d) = %q, want %q", test.str, test.width, got, test.want)
+		}
+	}
+}
+
+func TestGroupMemos(t *testing.T) {
+	memos := map[HASH]*Memo{
+		"1": {Title: "b", Tags: []string{"web", "db"}},
+		"2": {Title: "A", Tags: []string{"web"}},
+		"3": {Title: "c"},
+	}
+	got := []string{}
+	for _, group := range GroupMemos(memos) {
+		got = append(got, group.Tag+":"+strings.Join(group.Hashes, ","))
+	}
+	// Tags in order with untagged last, and titles in order ignoring case
+	if want := []string{"db:1", "web:2,1", GROUPED_UNTAGGED + ":3"}; !slices.Equal(got, want) {
+		t.Errorf("GroupMemos() = %q, want %q", got, want)
+	}
+}
+
+func TestGroupedLines(t *testing.T) {
+	memos := map[HASH]*Memo{
+		"1": {Title: "b", Content: "bee\nmore", Tags: []string{"x", "y"}},
+		"2": {Title: "A", Content: "  aye", Tags: []string{"x"}},
+		"3": {Title: "c", Content: "sea"},
+	}
+	pad := func(text string) string { return PadRight(text, 40) }
+	heading := func(tag string) string { return ESC_BOLD + pad(tag) + ESC_RESET }
+	gap := strings.Repeat(" ", GROUPED_COLUMN_GAP)
+	tests := []struct {
+		width int
+		want  []string
+	}{
+		{40, []string{
+			heading("x"), "A → aye", "b → bee", "",
+			heading("y"), "b → bee", "",
+			heading(GROUPED_UNTAGGED), "c → sea",
+		}},
+		// Blocks fill a column up to its share of the lines, then the next
+		{84, []string{
+			heading("x") + gap + heading(GROUPED_UNTAGGED),
+			pad("A → aye") + gap + "c → sea",
+			"b → bee",
+			"",
+			heading("y"),
+			"b → bee",
+		}},
+	}
+	for _, test := range tests {
+		if got := GroupedLines(memos, test.width); !slices.Equal(got, test.want) {
+			t.Errorf("GroupedLines(%d) =\n%q\nwant\n%q", test.width, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const TAB_WIDTH = 4
+
+type runeRange struct {
+	Lo rune
+	Hi rune
+}
+
+// East Asian Wide and Fullwidth characters plus emoji with default emoji
+// presentation, all of which take two terminal cells
+var wideRanges = []runeRange{
+	{0x1100, 0x115F}, {0x231A, 0x231B}, {0x2329, 0x232A}, {0x23E9, 0x23EC},
+	{0x23F0, 0x23F0}, {0x23F3, 0x23F3}, {0x25FD, 0x25FE}, {0x2614, 0x2615},
+	{0x2648, 0x2653}, {0x267F, 0x267F}, {0x2693, 0x2693}, {0x26A1, 0x26A1},
+	{0x26AA, 0x26AB}, {0x26BD, 0x26BE}, {0x26C4, 0x26C5}, {0x26CE, 0x26CE},
+	{0x26D4, 0x26D4}, {0x26EA, 0x26EA}, {0x26F2, 0x26F3}, {0x26F5, 0x26F5},
+	{0x26FA, 0x26FA}, {0x26FD, 0x26FD}, {0x2705, 0x2705}, {0x270A, 0x270B},
+	{0x2728, 0x2728}, {0x274C, 0x274C}, {0x274E, 0x274E}, {0x2753, 0x2755},
+	{0x2757, 0x2757}, {0x2795, 0x2797}, {0x27B0, 0x27B0}, {0x27BF, 0x27BF},
+	{0x2B1B, 0x2B1C}, {0x2B50, 0x2B50}, {0x2B55, 0x2B55}, {0x2E80, 0x303E},
+	{0x3041, 0x33FF}, {0x3400, 0x4DBF}, {0x4E00, 0x9FFF}, {0xA000, 0xA4CF},
+	{0xA960, 0xA97F}, {0xAC00, 0xD7A3}, {0xF900, 0xFAFF}, {0xFE10, 0xFE19},
+	{0xFE30, 0xFE6F}, {0xFF00, 0xFF60}, {0xFFE0, 0xFFE6}, {0x16FE0, 0x16FE4},
+	{0x17000, 0x18AFF}, {0x1B000, 0x1B2FF}, {0x1F004, 0x1F004}, {0x1F0CF, 0x1F0CF},
+	{0x1F18E, 0x1F18E}, {0x1F191, 0x1F19A}, {0x1F200, 0x1F251}, {0x1F300, 0x1F320},
+	{0x1F32D, 0x1F335}, {0x1F337, 0x1F37C}, {0x1F37E, 0x1F393}, {0x1F3A0, 0x1F3CA},
+	{0x1F3CF, 0x1F3D3}, {0x1F3E0, 0x1F3F0}, {0x1F3F4, 0x1F3F4}, {0x1F3F8, 0x1F43E},
+	{0x1F440, 0x1F440}, {0x1F442, 0x1F4FC}, {0x1F4FF, 0x1F53D}, {0x1F54B, 0x1F54E},
+	{0x1F550, 0x1F567}, {0x1F57A, 0x1F57A}, {0x1F595, 0x1F596}, {0x1F5A4, 0x1F5A4},
+	{0x1F5FB, 0x1F64F}, {0x1F680, 0x1F6C5}, {0x1F6CC, 0x1F6CC}, {0x1F6D0, 0x1F6D2},
+	{0x1F6D5, 0x1F6D7}, {0x1F6DC, 0x1F6DF}, {0x1F6EB, 0x1F6EC}, {0x1F6F4, 0x1F6FC},
+	{0x1F7E0, 0x1F7EB}, {0x1F7F0, 0x1F7F0}, {0x1F90C, 0x1F93A}, {0x1F93C, 0x1F945},
+	{0x1F947, 0x1F9FF}, {0x1FA70, 0x1FAFF}, {0x20000, 0x2FFFD}, {0x30000, 0x3FFFD},
+}
+
+func inRanges(r rune, ranges []runeRange) bool {
+	i := sort.Search(len(ranges), func(i int) bool {
+		return ranges[i].Hi >= r
+	})
+	return i < len(ranges) && ranges[i].Lo <= r
+}
+
+// Number of terminal cells r takes on its own
+func RuneWidth(r rune) int {
+	switch {
+	case r == '\t':
+		return TAB_WIDTH
+	case r < 0x20 || (r >= 0x7F && r < 0xA0):
+		return 0
+	case r >= 0x1160 && r <= 0x11FF:
+		// Hangul medial vowels and final consonants combine with the initial
+		return 0
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case inRanges(r, wideRanges):
+		return 2
+	}
+	return 1
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+// Whether r continues the grapheme cluster ending in prev
+func extendsCluster(prev rune, r rune, cluster_runes int) bool {
+	switch {
+	case prev == '\r' && r == '\n':
+		return true
+	case prev == 0x200D:
+		// Zero width joiner glues emoji sequences together
+		return true
+	case r == 0x200D:
+		return true
+	case r >= 0xFE00 && r <= 0xFE0F:
+		return true
+	case r >= 0x1F3FB && r <= 0x1F3FF:
+		// Emoji skin tone modifiers
+		return true
+	case r >= 0xE0020 && r <= 0xE007F:
+		// Tag sequences, e.g. subdivision flags
+		return true
+	case r >= 0x1160 && r <= 0x11FF:
+		return true
+	case isRegionalIndicator(prev) && isRegionalIndicator(r) && cluster_runes == 1:
+		return true
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc):
+		return true
+	}
+	return false
+}
+
+// Length in bytes of the ANSI escape sequence at the start of str, or 0
+func escapeLength(str string) int {
+	if len(str) < 2 || str[0] != 0x1b {
+		return 0
+	}
+	switch str[1] {
+	case '[':
+		for i := 2; i < len(str); i++ {
+			if str[i] >= 0x40 && str[i] <= 0x7E {
+				return i + 1
+			}
+		}
+		return len(str)
+	case ']':
+		for i := 2; i < len(str); i++ {
+			if str[i] == '\a' {
+				return i + 1
+			}
+			if str[i] == 0x1b && i+1 < len(str) && str[i+1] == '\\' {
+				return i + 2
+			}
+		}
+		return len(str)
+	}
+	return 2
+}
+
+// Splits str into user-perceived characters. ANSI escape sequences come back
+// as clusters of their own, which have no width.
+func Graphemes(str string) []string {
+	clusters := []string{}
+	start := 0
+	var prev rune = -1
+	cluster_runes := 0
+	for i := 0; i < len(str); {
+		if escape_length := escapeLength(str[i:]); escape_length > 0 {
+			if i > start {
+				clusters = append(clusters, str[start:i])
+			}
+			clusters = append(clusters, str[i:i+escape_length])
+			i += escape_length
+			start = i
+			prev = -1
+			cluster_runes = 0
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(str[i:])
+		if i > start && !extendsCluster(prev, r, cluster_runes) {
+			clusters = append(clusters, str[start:i])
+			start = i
+			cluster_runes = 0
+		}
+		prev = r
+		cluster_runes++
+		i += size
+	}
+	if start < len(str) {
+		clusters = append(clusters, str[start:])
+	}
+	return clusters
+}
+
+func GraphemeWidth(cluster string) int {
+	if escapeLength(cluster) > 0 {
+		return 0
+	}
+	first, _ := utf8.DecodeRuneInString(cluster)
+	width := RuneWidth(first)
+	if isRegionalIndicator(first) && utf8.RuneCountInString(cluster) > 1 {
+		return 2
+	}
+	// Emoji presentation selector turns narrow symbols into emoji
+	if width == 1 && strings.ContainsRune(cluster, 0xFE0F) {
+		return 2
+	}
+	return width
+}
+
+// Number of terminal cells str takes up, ignoring ANSI escape sequences
+func StringWidth(str string) int {
+	width := 0
+	for _, cluster := range Graphemes(str) {
+		width += GraphemeWidth(cluster)
+	}
+	return width
+}
+
+// Replaces tabs with spaces up to the next tab stop
+func ExpandTabs(line string) string {
+	if !strings.Contains(line, "\t") {
+		return line
+	}
+	var expanded strings.Builder
+	column := 0
+	for _, cluster := range Graphemes(line) {
+		if cluster == "\t" {
+			spaces := TAB_WIDTH - column%TAB_WIDTH
+			expanded.WriteString(strings.Repeat(" ", spaces))
+			column += spaces
+		} else {
+			expanded.WriteString(cluster)
+			column += GraphemeWidth(cluster)
+		}
+	}
+	return expanded.String()
+}
+
+// Splits str into pieces no wider than width without breaking up characters.
+// A single character wider than width gets a piece of its own.
+func HardWrap(str string, width int) []string {
+	pieces := []string{}
+	piece := ""
+	piece_width := 0
+	for _, cluster := range Graphemes(str) {
+		cluster_width := GraphemeWidth(cluster)
+		if piece_width > 0 && piece_width+cluster_width > width {
+			pieces = append(pieces, piece)
+			piece = ""
+			piece_width = 0
+		}
+		piece += cluster
+		piece_width += cluster_width
+	}
+	if piece != "" {
+		pieces = append(pieces, piece)
+	}
+	return pieces
+}
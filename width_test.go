@@ -0,0 +1,84 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestStringWidth(t *testing.T) {
+	tests := []struct {
+		str  string
+		want int
+	}{
+		{"", 0},
+		{"abc", 3},
+		{"héllo", 5},
+		{"é", 1},
+		{"日本", 4},
+		{"\x1b[31mred\x1b[0m", 3},
+		{"\t", TAB_WIDTH},
+		{"👍", 2},
+		{"👍🏽", 2},
+		{"👨‍👩‍👧", 2},
+		{"🇯🇵", 2},
+		{"❤️", 2},
+	}
+	for _, test := range tests {
+		if got := StringWidth(test.str); got != test.want {
+			t.Errorf("StringWidth(%q) = %d, want %d", test.str, got, test.want)
+		}
+	}
+}
+
+func TestGraphemes(t *testing.T) {
+	tests := []struct {
+		str  string
+		want []string
+	}{
+		{"", []string{}},
+		{"ab", []string{"a", "b"}},
+		{"éx", []string{"é", "x"}},
+		{"🇯🇵🇫🇷", []string{"🇯🇵", "🇫🇷"}},
+		{"a\x1b[1mb", []string{"a", "\x1b[1m", "b"}},
+	}
+	for _, test := range tests {
+		if got := Graphemes(test.str); !slices.Equal(got, test.want) {
+			t.Errorf("Graphemes(%q) = %q, want %q", test.str, got, test.want)
+		}
+	}
+}
+
+func TestExpandTabs(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"no tabs", "no tabs"},
+		{"\tx", "    x"},
+		{"a\tb", "a   b"},
+		{"日\tb", "日  b"},
+	}
+	for _, test := range tests {
+		if got := ExpandTabs(test.line); got != test.want {
+			t.Errorf("ExpandTabs(%q) = %q, want %q", test.line, got, test.want)
+		}
+	}
+}
+
+func TestHardWrap(t *testing.T) {
+	tests := []struct {
+		str   string
+		width int
+		want  []string
+	}{
+		{"abcdef", 4, []string{"abcd", "ef"}},
+		{"日本語", 3, []string{"日", "本", "語"}},
+		{"日", 1, []string{"日"}},
+		{"", 3, []string{}},
+	}
+	for _, test := range tests {
+		if got := HardWrap(test.str, test.width); !slices.Equal(got, test.want) {
+			t.Errorf("HardWrap(%q, %d) = %q, want %q", test.str, test.width, got, test.want)
+		}
+	}
+}